@@ -6,8 +6,11 @@ import (
 	"syscall"
 
 	"github.com/krane/krane/internal/api"
+	"github.com/krane/krane/internal/auth"
 	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/deployment"
 	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/forwarder"
 	"github.com/krane/krane/internal/job"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/scheduler"
@@ -24,7 +27,19 @@ func init() {
 	utils.EnvOrDefault(constants.EnvJobQueueSize, "1")
 	utils.EnvOrDefault(constants.EnvJobMaxRetryPolicy, "5")
 	utils.EnvOrDefault(constants.EnvDeploymentRetryPolicy, "1")
+	utils.EnvOrDefault(constants.EnvJobRetryBackoffMs, "2000")
+	utils.EnvOrDefault(constants.EnvJobRetryMaxBackoffMs, utils.OneMinMs)
+	utils.EnvOrDefault(constants.EnvJobTimeoutMs, "1800000")
 	utils.EnvOrDefault(constants.EnvSchedulerIntervalMs, "30000")
+	utils.EnvOrDefault(constants.EnvSchedulerCooldownMs, utils.OneMinMs)
+	utils.EnvOrDefault(constants.EnvLogForwarderIntervalMs, "10000")
+	utils.EnvOrDefault(constants.EnvLogSinkDir, "/var/log/krane")
+	utils.EnvOrDefault(constants.EnvEventRetentionMs, "604800000")
+	utils.EnvOrDefault(constants.EnvSessionTTLMs, "86400000")
+	utils.EnvOrDefault(constants.EnvAccessTokenTTLMs, "604800000")
+	utils.EnvOrDefault(constants.EnvSessionMaxAgeMs, "604800000")
+	utils.EnvOrDefault(constants.EnvLoginRequestTTLMs, utils.FiveMinMs)
+	utils.EnvOrDefault(constants.EnvSessionSweepIntervalMs, utils.FiveMinMs)
 	utils.EnvOrDefault(constants.EnvWatchMode, "false")
 	utils.EnvOrDefault(constants.EnvDockerBasicAuthUsername, "")
 	utils.EnvOrDefault(constants.EnvDockerBasicAuthPassword, "")
@@ -60,17 +75,33 @@ func main() {
 		logger.Warn("The feature watch mode is experimental. Krane will attempt to keep your containers state as close to you deployment configuration even when deployments arent triggered.")
 		enqueuer := job.NewEnqueuer(queue)
 		interval := utils.EnvOrDefault(constants.EnvSchedulerIntervalMs, utils.TwoMinMs)
+		cooldown := utils.EnvOrDefault(constants.EnvSchedulerCooldownMs, utils.OneMinMs)
 
-		jobScheduler := scheduler.New(db, docker.GetClient(), enqueuer, interval)
+		jobScheduler := scheduler.New(db, docker.GetClient(), enqueuer, interval, cooldown)
 		go jobScheduler.Run()
 	}
 
+	// container logs of deployments configured with log sinks are forwarded to those sinks
+	logForwarder := forwarder.New(os.Getenv(constants.EnvLogForwarderIntervalMs))
+	go logForwarder.Run()
+
+	// recorded deployment events are only kept for the event retention
+	eventPruner := deployment.NewEventPruner(os.Getenv(constants.EnvEventRetentionMs))
+	go eventPruner.Run()
+
+	// expired sessions and login requests never used to authenticate are deleted
+	sessionSweeper := auth.NewSweeper(os.Getenv(constants.EnvSessionSweepIntervalMs))
+	go sessionSweeper.Run()
+
 	// workers for executing deployment jobs; when no workers are instantiated,
 	// queued jobs will block until a worker is added to the worker pool.
 	wpSize := utils.UIntEnv(constants.EnvWorkerPoolSize)
 	workers := job.NewWorkerPool(wpSize, queue, store.Client())
 	workers.Start()
 
+	// jobs queued or running when Krane last stopped are resumed or marked as failed
+	go job.ResumeInterrupted(queue, deployment.RebuildJob)
+
 	// if enabled, ensure internal services are running
 	EnsureNetworkProxy()
 
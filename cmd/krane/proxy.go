@@ -79,7 +79,7 @@ func EnsureNetworkProxy() {
 }
 
 func createProxy() error {
-	if err := deployment.SaveConfig(proxyConfig); err != nil {
+	if err := deployment.SaveConfig(proxyConfig, "krane"); err != nil {
 		return err
 	}
 
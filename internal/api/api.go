@@ -9,6 +9,7 @@ import (
 	"github.com/krane/krane/internal/api/middlewares"
 	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/session"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -22,11 +23,12 @@ func Run() {
 	withBaseMiddlewares(router)
 	withRoutes(router)
 
+	// the server has no write timeout so realtime streams are not cut off,
+	// requests other than streams are bounded by the request timeout instead
 	srv := http.Server{
-		Handler:      router,
-		Addr:         os.Getenv(constants.EnvListenAddress),
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+		Handler:     router,
+		Addr:        os.Getenv(constants.EnvListenAddress),
+		ReadTimeout: 15 * time.Second,
 	}
 
 	logger.Infof("Krane API on %s", srv.Addr)
@@ -35,57 +37,101 @@ func Run() {
 	}
 }
 
+// time allowed to respond to requests which are not realtime streams
+const requestTimeout = 15 * time.Second
+
 // withBaseMiddlewares configures rest api middlewares
 func withBaseMiddlewares(router *mux.Router) {
 	router.Use(middlewares.Logging)
+	router.Use(middlewares.Metrics)
 	router.Use(handlers.RecoveryHandler())
 	router.Use(handlers.CORS(
 		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost}),
 		handlers.AllowedOrigins([]string{"*"})))
 }
 
-// withRoutes configures rest api endpoints and handlers
+// withRoutes configures rest api endpoints and handlers. Authenticated routes require a session granted
+// a role: viewers can read deployments, jobs and logs, deployers can also run and update deployments,
+// admins can also manage secrets, sessions and exec into containers. Sessions restricted to some
+// deployments can only access those deployments and cannot manage sessions. Every session can refresh its token.
 func withRoutes(router *mux.Router) {
 	noAuthRouter := router.PathPrefix("/").Subrouter()
+	noAuthRouter.Use(middlewares.Timeout(requestTimeout))
 	withRoute(noAuthRouter, "/", controllers.RootPath).Methods(http.MethodGet)
 	withRoute(noAuthRouter, "/health", controllers.HealthCheck).Methods(http.MethodGet)
+	withRoute(noAuthRouter, "/metrics", controllers.Metrics).Methods(http.MethodGet)
 	withRoute(noAuthRouter, "/login", controllers.RequestLoginPhrase).Methods(http.MethodGet)
 	withRoute(noAuthRouter, "/auth", controllers.AuthenticateClientJWT).Methods(http.MethodPost)
 
+	viewer := withRole(session.ViewerRole)
+	deployer := withRole(session.DeployerRole)
+	admin := withRole(session.AdminRole)
+	unrestrictedAdmin := append(withRole(session.AdminRole), middlewares.RequireUnrestrictedScope)
+
 	authRouter := router.PathPrefix("/").Subrouter()
+	authRouter.Use(middlewares.Timeout(requestTimeout))
 	// deployments
-	withRoute(authRouter, "/deployments", controllers.GetAllDeployments, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/deployments", controllers.CreateOrUpdateDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/deployments/{deployment}", controllers.GetDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/deployments/{deployment}", controllers.RunDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/deployments/{deployment}", controllers.DeleteDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
-	withRoute(authRouter, "/deployments/{deployment}/containers", controllers.GetDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/deployments/{deployment}/containers/start", controllers.StartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/deployments/{deployment}/containers/stop", controllers.StopDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/deployments/{deployment}/containers/restart", controllers.RestartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments", controllers.GetAllDeployments, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments", controllers.CreateOrUpdateDeployment, deployer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}", controllers.GetDeployment, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}", controllers.RunDeployment, deployer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}", controllers.DeleteDeployment, deployer...).Methods(http.MethodDelete)
+	withRoute(authRouter, "/deployments/{deployment}/containers", controllers.GetDeploymentContainers, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/logs", controllers.GetDeploymentLogs, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/stats", controllers.GetDeploymentStats, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/containers/start", controllers.StartDeploymentContainers, deployer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/containers/stop", controllers.StopDeploymentContainers, deployer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/containers/restart", controllers.RestartDeploymentContainers, deployer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/revisions", controllers.GetDeploymentRevisions, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/revisions/diff", controllers.DiffDeploymentRevisions, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/revisions/{revision:[0-9]+}", controllers.GetDeploymentRevision, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/rollback/{revision:[0-9]+}", controllers.RollbackDeployment, deployer...).Methods(http.MethodPost)
 	// secrets
-	withRoute(authRouter, "/secrets/{deployment}", controllers.GetSecrets, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/secrets/{deployment}", controllers.CreateOrUpdateSecret, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/secrets/{deployment}/{key}", controllers.DeleteSecret, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/secrets/{deployment}", controllers.GetSecrets, admin...).Methods(http.MethodGet)
+	withRoute(authRouter, "/secrets/{deployment}", controllers.CreateOrUpdateSecret, admin...).Methods(http.MethodPost)
+	withRoute(authRouter, "/secrets/{deployment}/{key}", controllers.DeleteSecret, admin...).Methods(http.MethodDelete)
 	// jobs
-	withRoute(authRouter, "/jobs", controllers.GetRecentJobs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/jobs/{deployment}", controllers.GetJobsByDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/jobs/{deployment}/{id}", controllers.GetJobByID, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/jobs", controllers.GetRecentJobs, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/jobs/{deployment}", controllers.GetJobsByDeployment, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/jobs/{deployment}/{id}", controllers.GetJobByID, viewer...).Methods(http.MethodGet)
+	withRoute(authRouter, "/jobs/{deployment}/{id}", controllers.CancelJob, deployer...).Methods(http.MethodDelete)
+	withRoute(authRouter, "/jobs/{deployment}/{id}/events", controllers.GetJobEvents, viewer...).Methods(http.MethodGet)
 	// sessions
-	withRoute(authRouter, "/sessions", controllers.GetSessions, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/sessions", controllers.CreateSession, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
-	withRoute(authRouter, "/sessions/{id}", controllers.DeleteSession, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
-	// realtime
-	withRoute(authRouter, "/ws/containers/{container}/logs", controllers.SubscribeToContainerLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/ws/deployments/{deployment}/logs", controllers.SubscribeToDeploymentLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/ws/deployments/{deployment}/events", controllers.SubscribeToDeploymentEvents, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/sessions", controllers.GetSessions, unrestrictedAdmin...).Methods(http.MethodGet)
+	withRoute(authRouter, "/sessions", controllers.CreateSession, unrestrictedAdmin...).Methods(http.MethodPost)
+	withRoute(authRouter, "/sessions/refresh", controllers.RefreshSession, viewer...).Methods(http.MethodPost)
+	withRoute(authRouter, "/sessions/{id}", controllers.DeleteSession, unrestrictedAdmin...).Methods(http.MethodDelete)
+	// realtime, streams run for as long as the client is connected
+	streamRouter := router.PathPrefix("/").Subrouter()
+	withRoute(streamRouter, "/ws/containers/{container}/logs", controllers.SubscribeToContainerLogs, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/ws/containers/{container}/exec", controllers.ExecInContainer, admin...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/ws/deployments/{deployment}/logs", controllers.SubscribeToDeploymentLogs, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/ws/deployments/{deployment}/stats", controllers.SubscribeToDeploymentStats, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/ws/deployments/{deployment}/events", controllers.SubscribeToDeploymentEvents, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/ws/events", controllers.SubscribeToEvents, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/sse/containers/{container}/logs", controllers.StreamContainerLogs, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/sse/deployments/{deployment}/logs", controllers.StreamDeploymentLogs, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/sse/deployments/{deployment}/events", controllers.StreamDeploymentEvents, viewer...).Methods(http.MethodGet)
+	withRoute(streamRouter, "/sse/events", controllers.StreamEvents, viewer...).Methods(http.MethodGet)
 }
 
 type routeHandler func(http.ResponseWriter, *http.Request)
 
+// withRoute registers a route handler, middlewares only apply to that route and run in the order provided
 func withRoute(r *mux.Router, path string, handler routeHandler, middlewares ...mux.MiddlewareFunc) *mux.Route {
-	for _, mw := range middlewares {
-		r.Use(mw)
+	var h http.Handler = http.HandlerFunc(handler)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return r.Handle(path, h)
+}
+
+// withRole returns the middlewares authenticating a session and requiring it to be granted a role,
+// requests for a deployment outside of the session scope are rejected
+func withRole(role session.Role) []mux.MiddlewareFunc {
+	return []mux.MiddlewareFunc{
+		middlewares.ValidateSessionMiddleware,
+		middlewares.RequireRole(role),
+		middlewares.RequireDeploymentScope,
 	}
-	return r.HandleFunc(path, handler)
 }
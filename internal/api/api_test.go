@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMiddlewaresOnlyApplyToTheirRoute(t *testing.T) {
+	calls := make([]string, 0)
+	middleware := func(name string) mux.MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler") }
+
+	router := mux.NewRouter()
+	withRoute(router, "/secrets", handler, middleware("authenticate"), middleware("admin")).Methods(http.MethodGet)
+	withRoute(router, "/deployments", handler, middleware("authenticate"), middleware("viewer")).Methods(http.MethodGet)
+	withRoute(router, "/health", handler).Methods(http.MethodGet)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/deployments", nil))
+	assert.Equal(t, []string{"authenticate", "viewer", "handler"}, calls)
+
+	calls = calls[:0]
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, []string{"handler"}, calls)
+}
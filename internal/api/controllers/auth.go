@@ -3,22 +3,21 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"strings"
 
-	"github.com/docker/distribution/uuid"
-
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/auth"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/session"
-	"github.com/krane/krane/internal/utils"
 )
 
 // AuthRequest represents the payload expected when authenticating with Krane
 type AuthRequest struct {
 	RequestID string `json:"request_id" binding:"required"`
 	Token     string `json:"token" binding:"required"`
+	TTL       string `json:"ttl"` // session time to live (ie. 30m, 12h), defaults to SESSION_TTL_MS
 }
 
 // LoginResponse is the response received when you initially want to authenticate.
@@ -55,6 +54,12 @@ func AuthenticateClientJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ttl, err := session.ParseTTL(body.TTL, session.LoginTTL())
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
 	// We check if the request id is valid to ensure we've stored a generate server
 	// phrase for that client id during the initial login request
 	serverPhrase, err := auth.GetAuthenticationPhrase(body.RequestID)
@@ -63,10 +68,10 @@ func AuthenticateClientJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Grab all the authorized keys on the host machine
-	// which will be used to decode the jwt token
-	authKeys := auth.GetServerAuthorizeKeys()
-	if len(authKeys) == 0 || authKeys[0] == "" {
+	// Grab the authorized keys on the host machine usable from the client address
+	// (expiry-time and from= options) which will be used to decode the jwt token
+	authKeys := auth.GetServerAuthorizeKeysFor(remoteIP(r))
+	if len(authKeys) == 0 {
 		logger.Warn("no authorized keys found on the server")
 		response.HTTPBad(w, errors.New("unable to authenticate"))
 		return
@@ -75,12 +80,13 @@ func AuthenticateClientJWT(w http.ResponseWriter, r *http.Request) {
 	// If any public key can be used to parse the incoming jwt token decode it,
 	// and passes the phrase comparison between incoming and server phrase,
 	// that token will be
-	claims := session.VerifyAuthTokenWithAuthorizedKeys(authKeys, body.Token)
+	claims, authKey := session.VerifyAuthTokenWithAuthorizedKeys(authKeys, body.Token)
 	if claims == nil || strings.Compare(serverPhrase, claims.Phrase) != 0 {
 		logger.Warn("no authorized keys found on the server")
 		response.HTTPBad(w, errors.New("invalid token"))
 		return
 	}
+
 	// revoke the request id to ensure no one else can
 	// use the same request id to create tokens
 	if err := auth.RevokeAuthenticationRequest(body.RequestID); err != nil {
@@ -88,26 +94,31 @@ func AuthenticateClientJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionTkn := session.Token{SessionID: uuid.Generate().String()}
-	signedTkn, err := session.CreateSessionToken(auth.GetServerPrivateKey(), sessionTkn)
+	newSession, err := session.New(auth.GetServerPrivateKey(), sessionUser(authKey), session.AdminRole, nil, ttl)
 	if err != nil {
-		logger.Errorf("unable to create session token %v", err)
+		logger.Errorf("unable to create session %v", err)
 		response.HTTPBad(w, err)
 		return
 	}
 
-	newSession := session.Session{
-		ID:        sessionTkn.SessionID,
-		Token:     signedTkn,
-		ExpiresAt: utils.UnixToDate(utils.OneYear),
-		User:      "root", // TODO: handle unique users
-	}
+	response.HTTPOk(w, newSession)
+	return
+}
 
-	if err := session.Save(newSession); err != nil {
-		response.HTTPBad(w, err)
-		return
+// remoteIP returns the ip address of the client sending a request
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
+	return host
+}
 
-	response.HTTPOk(w, newSession)
-	return
+// sessionUser returns the user of a session created with an authorized key, keys are
+// identified by their comment (ie. alice@example.com) and keys without a comment by root
+func sessionUser(key session.AuthorizedKey) string {
+	if key.Comment == "" {
+		return "root"
+	}
+	return key.Comment
 }
@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/deployment"
 	"github.com/krane/krane/internal/session"
+	"github.com/krane/krane/internal/utils"
 )
 
 // WSUpgrader upgrades HTTP connections to WebSocket connections
@@ -47,19 +52,28 @@ func GetDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.HTTPOk(w, d)
+	response.HTTPOk(w, d.Redacted())
 	return
 }
 
 // GetAllDeployments returns a list of deployments with their configurations, containers and recent activity
-func GetAllDeployments(w http.ResponseWriter, _ *http.Request) {
+func GetAllDeployments(w http.ResponseWriter, r *http.Request) {
 	deployments, err := deployment.GetAllDeployments()
 	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPOk(w, deployments)
+	// only deployments within the session scope are listed
+	s := r.Context().Value("session").(session.Session)
+	scoped := make([]deployment.Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		if s.Scope.Allows(d.Config.Name) {
+			scoped = append(scoped, d.Redacted())
+		}
+	}
+
+	response.HTTPOk(w, scoped)
 	return
 }
 
@@ -72,12 +86,18 @@ func CreateOrUpdateDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deployment.SaveConfig(config); err != nil {
+	s := r.Context().Value("session").(session.Session)
+	if !s.Scope.Allows(config.Name) {
+		response.HTTPForbidden(w, fmt.Errorf("deployment %s is outside of the session scope", config.Name))
+		return
+	}
+
+	if err := deployment.SaveConfig(config, s.User); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPOk(w, config)
+	response.HTTPOk(w, config.Redacted())
 	return
 }
 
@@ -154,6 +174,92 @@ func GetDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// GetDeploymentLogs returns the logs of all containers for a deployment merged and ordered by timestamp.
+// Logs can be bound in time (since, until), limited to the last lines (tail), limited to a stream
+// (stdout=false or stderr=false) and filtered by substring (filter) or regular expression (regex).
+func GetDeploymentLogs(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	query, err := logQueryFromRequest(r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	logs, err := deployment.GetDeploymentLogs(deploymentName, query)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, logs)
+	return
+}
+
+// logQueryFromRequest returns the deployment logs query from the request query params
+func logQueryFromRequest(r *http.Request) (deployment.LogQuery, error) {
+	now := time.Now()
+
+	since, err := deployment.ParseLogTime(utils.QueryParamOrDefault(r, "since", ""), now)
+	if err != nil {
+		return deployment.LogQuery{}, fmt.Errorf("invalid since, %v", err)
+	}
+
+	until, err := deployment.ParseLogTime(utils.QueryParamOrDefault(r, "until", ""), now)
+	if err != nil {
+		return deployment.LogQuery{}, fmt.Errorf("invalid until, %v", err)
+	}
+
+	tail, err := strconv.Atoi(utils.QueryParamOrDefault(r, "tail", "0"))
+	if err != nil || tail < 0 {
+		return deployment.LogQuery{}, errors.New("tail must be a positive number")
+	}
+
+	stdout, err := strconv.ParseBool(utils.QueryParamOrDefault(r, "stdout", "true"))
+	if err != nil {
+		return deployment.LogQuery{}, errors.New("stdout must be true or false")
+	}
+
+	stderr, err := strconv.ParseBool(utils.QueryParamOrDefault(r, "stderr", "true"))
+	if err != nil {
+		return deployment.LogQuery{}, errors.New("stderr must be true or false")
+	}
+
+	if !stdout && !stderr {
+		return deployment.LogQuery{}, errors.New("at least one of stdout or stderr must be selected")
+	}
+
+	query := deployment.LogQuery{
+		Since:  since,
+		Until:  until,
+		Tail:   tail,
+		Stdout: stdout,
+		Stderr: stderr,
+		Filter: utils.QueryParamOrDefault(r, "filter", ""),
+	}
+
+	if pattern := utils.QueryParamOrDefault(r, "regex", ""); pattern != "" {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return deployment.LogQuery{}, fmt.Errorf("invalid regex, %v", err)
+		}
+		query.Regex = regex
+	}
+
+	return query, nil
+}
+
 // StartDeploymentContainers starts all containers (if any) for a deployment
 // Note: this does not create any containers, only start already existing ones
 func StartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
@@ -240,11 +346,11 @@ func SubscribeToContainerLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deployment.SubscribeToContainerLogs(connection, container)
+	deployment.SubscribeToContainerLogs(deployment.NewWebSocketClient(connection), container)
 	return
 }
 
-// SubscribeToDeploymentLogs opens a websocket connection and subscribes the client to deployment events
+// SubscribeToDeploymentLogs opens a websocket connection and subscribes the client to deployment logs
 func SubscribeToDeploymentLogs(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
@@ -255,21 +361,178 @@ func SubscribeToDeploymentLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deployment.SubscribeToDeploymentLogs(connection, deploymentName)
+	deployment.SubscribeToDeploymentLogs(deployment.NewWebSocketClient(connection), deploymentName)
 	return
 }
 
-// SubscribeToDeploymentEvents opens a websocket connection and subscribes the client to deployment events
+// SubscribeToDeploymentEvents opens a websocket connection and subscribes the client to deployment events.
+// Recorded events are replayed for a job (job_id) or starting at a sequence number (from_sequence) when provided.
 func SubscribeToDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
 
+	replay, err := eventReplayFromRequest(r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	connection, err := WSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToDeploymentEvents(deployment.NewWebSocketClient(connection), deploymentName, replay)
+	return
+}
+
+// SubscribeToEvents opens a websocket connection and subscribes the client to the events of every deployment.
+// Events can be filtered by deployment and phase using comma separated lists (ie. ?deployment=api,web&phase=PULL_IMAGE)
+func SubscribeToEvents(w http.ResponseWriter, r *http.Request) {
+	connection, err := WSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToEvents(deployment.NewWebSocketClient(connection), eventFilterFromRequest(r))
+	return
+}
+
+// eventReplayFromRequest returns the recorded events to replay when subscribing to deployment events. Events
+// are replayed for a job (job_id) or starting at a sequence number (from_sequence). SSE clients reconnecting
+// with a Last-Event-ID header are replayed the events following the last event they received.
+func eventReplayFromRequest(r *http.Request) (deployment.EventReplay, error) {
+	fromSequence, err := strconv.ParseUint(utils.QueryParamOrDefault(r, "from_sequence", "0"), 10, 64)
+	if err != nil {
+		return deployment.EventReplay{}, errors.New("from_sequence must be a positive number")
+	}
+
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" && fromSequence == 0 {
+		lastSequence, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return deployment.EventReplay{}, errors.New("Last-Event-ID must be a positive number")
+		}
+		fromSequence = lastSequence + 1
+	}
+
+	return deployment.EventReplay{
+		JobID:        utils.QueryParamOrDefault(r, "job_id", ""),
+		FromSequence: fromSequence,
+	}, nil
+}
+
+// eventFilterFromRequest returns the deployment and phase filters for events from comma separated query params
+func eventFilterFromRequest(r *http.Request) deployment.EventFilter {
+	filter := deployment.EventFilter{
+		Deployments: make([]string, 0),
+		Phases:      make([]deployment.Phase, 0),
+	}
+
+	// only events of deployments within the session scope are delivered
+	if s, ok := r.Context().Value("session").(session.Session); ok && !s.Scope.Unrestricted() {
+		filter.InScope = s.Scope.Allows
+	}
+
+	for _, d := range strings.Split(utils.QueryParamOrDefault(r, "deployment", ""), ",") {
+		if d != "" {
+			filter.Deployments = append(filter.Deployments, d)
+		}
+	}
+
+	for _, p := range strings.Split(utils.QueryParamOrDefault(r, "phase", ""), ",") {
+		if p != "" {
+			filter.Phases = append(filter.Phases, deployment.Phase(strings.ToUpper(p)))
+		}
+	}
+
+	return filter
+}
+
+// GetDeploymentStats returns a sample of the resource usage (cpu, memory, network and block io) of every running
+// container of a deployment
+func GetDeploymentStats(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	stats, err := deployment.GetDeploymentStats(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, stats)
+	return
+}
+
+// SubscribeToDeploymentStats opens a websocket connection and subscribes the client to the resource usage of
+// every running container of a deployment
+func SubscribeToDeploymentStats(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	connection, err := WSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToDeploymentStats(deployment.NewWebSocketClient(connection), deploymentName)
+	return
+}
+
+// ExecInContainer opens a websocket connection running a command in a container managed by Krane. The command
+// is set with the cmd query param (repeated for every argument), along with tty, env (repeated KEY=value) and user.
+func ExecInContainer(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	container := params["container"]
+
+	tty, err := strconv.ParseBool(utils.QueryParamOrDefault(r, "tty", "false"))
+	if err != nil {
+		response.HTTPBad(w, errors.New("tty must be true or false"))
+		return
+	}
+
+	query := r.URL.Query()
+	for _, env := range query["env"] {
+		if !strings.Contains(env, "=") {
+			response.HTTPBad(w, fmt.Errorf("invalid env %s, expected KEY=value", env))
+			return
+		}
+	}
+
+	exec, err := deployment.CreateExec(container, deployment.ExecOptions{
+		Cmd:  query["cmd"],
+		Tty:  tty,
+		Env:  query["env"],
+		User: query.Get("user"),
+	})
+	if err == deployment.ErrExecNotPermitted {
+		response.HTTPForbidden(w, err)
+		return
+	}
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
 	connection, err := WSUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	deployment.SubscribeToDeploymentEvents(connection, deploymentName)
+	deployment.AttachExec(connection, exec)
 	return
 }
@@ -10,6 +10,8 @@ import (
 
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/session"
 	"github.com/krane/krane/internal/utils"
 )
 
@@ -24,7 +26,16 @@ func GetRecentJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.HTTPOk(w, jobs)
+	// only jobs of deployments within the session scope are listed
+	s := r.Context().Value("session").(session.Session)
+	scoped := make([]job.Job, 0, len(jobs))
+	for _, j := range jobs {
+		if s.Scope.Allows(j.Deployment) {
+			scoped = append(scoped, j)
+		}
+	}
+
+	response.HTTPOk(w, scoped)
 	return
 }
 
@@ -89,3 +100,65 @@ func GetJobByID(w http.ResponseWriter, r *http.Request) {
 	response.HTTPOk(w, j)
 	return
 }
+
+// CancelJob cancels a queued or running job
+func CancelJob(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+	jobID := params["id"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if jobID == "" {
+		response.HTTPBad(w, errors.New("job id not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	j, err := deployment.CancelJob(deploymentName, jobID)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAcceptedWithBody(w, j)
+	return
+}
+
+// GetJobEvents returns the recorded events of a job in the order they were emitted
+func GetJobEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+	jobID := params["id"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if jobID == "" {
+		response.HTTPBad(w, errors.New("job id not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	events, err := deployment.GetJobEvents(deploymentName, jobID)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, events)
+	return
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var metricsHandler = promhttp.Handler()
+
+// Metrics exposes Krane metrics (job queue, workers, scheduler, api latency, subscribers and containers) in the Prometheus format
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	metricsHandler.ServeHTTP(w, r)
+}
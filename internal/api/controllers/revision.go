@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/session"
+	"github.com/krane/krane/internal/utils"
+)
+
+// GetDeploymentRevisions returns every saved configuration revision for a deployment
+func GetDeploymentRevisions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	revisions, err := deployment.GetRevisions(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	redacted := make([]deployment.Revision, 0, len(revisions))
+	for _, rev := range revisions {
+		redacted = append(redacted, rev.Redacted())
+	}
+
+	response.HTTPOk(w, redacted)
+	return
+}
+
+// GetDeploymentRevision returns a single configuration revision for a deployment
+func GetDeploymentRevision(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	revision, err := strconv.Atoi(params["revision"])
+	if err != nil {
+		response.HTTPBad(w, fmt.Errorf("invalid revision %s", params["revision"]))
+		return
+	}
+
+	rev, err := deployment.GetRevision(deploymentName, revision)
+	if err != nil {
+		response.HTTPNotFound(w, err)
+		return
+	}
+
+	response.HTTPOk(w, rev.Redacted())
+	return
+}
+
+// DiffDeploymentRevisions returns the configuration changes between two revisions of a deployment.
+// The query params from & to default to the previous and latest revision.
+func DiffDeploymentRevisions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	revisions, err := deployment.GetRevisions(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if len(revisions) == 0 {
+		response.HTTPNotFound(w, fmt.Errorf("no revisions found for deployment %s", deploymentName))
+		return
+	}
+
+	latest := revisions[len(revisions)-1].Revision
+
+	to, err := strconv.Atoi(utils.QueryParamOrDefault(r, "to", strconv.Itoa(latest)))
+	if err != nil {
+		response.HTTPBad(w, errors.New("invalid revision provided for to"))
+		return
+	}
+
+	from, err := strconv.Atoi(utils.QueryParamOrDefault(r, "from", strconv.Itoa(to-1)))
+	if err != nil {
+		response.HTTPBad(w, errors.New("invalid revision provided for from"))
+		return
+	}
+
+	changes, err := deployment.DiffRevisions(deploymentName, from, to)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, changes)
+	return
+}
+
+// RollbackDeployment restores the configuration of a previous revision and runs the deployment
+func RollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	revision, err := strconv.Atoi(params["revision"])
+	if err != nil {
+		response.HTTPBad(w, fmt.Errorf("invalid revision %s", params["revision"]))
+		return
+	}
+
+	s := r.Context().Value("session").(session.Session)
+	if err := deployment.RollbackToRevision(deploymentName, revision, s.User); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAccepted(w)
+	return
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/session"
+	"github.com/krane/krane/internal/utils/test"
+)
+
+func TestMain(m *testing.M) {
+	test.SetupDb()
+
+	code := m.Run()
+
+	test.TeardownDb()
+	os.Exit(code)
+}
+
+func TestViewerCannotReadLogSinkHeaders(t *testing.T) {
+	name := "krane-controllers-sink-test"
+	defer deployment.DeleteConfig(name)
+	defer deployment.DeleteRevisionsCollection(name)
+
+	sink := deployment.LogSink{Type: deployment.HTTPLogSink, URL: "https://logs.example.com", Headers: map[string]string{"Authorization": "Bearer secret"}}
+	assert.Nil(t, deployment.SaveConfig(deployment.Config{Name: name, Image: "biensupernice/krane", LogSinks: []deployment.LogSink{sink}}, "root"))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/deployments/{deployment}/revisions", GetDeploymentRevisions)
+	router.HandleFunc("/deployments/{deployment}/revisions/{revision}", GetDeploymentRevision)
+
+	viewer := session.Session{ID: "viewer", User: "ci", Role: session.ViewerRole}
+	for _, path := range []string{"/deployments/" + name + "/revisions", "/deployments/" + name + "/revisions/1"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), "session", viewer))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotContains(t, w.Body.String(), "Bearer secret", path)
+		assert.Contains(t, w.Body.String(), "redacted", path)
+	}
+}
+
+func TestGetRevisionOfMissingDeployment(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/deployments/{deployment}/revisions/{revision}", GetDeploymentRevision)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deployments/krane-missing-test/revisions/1", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "deployment krane-missing-test does not exist", w.Body.String())
+}
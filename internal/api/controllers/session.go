@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/docker/distribution/uuid"
 	"github.com/gorilla/mux"
 
 	"github.com/krane/krane/internal/api/response"
@@ -16,7 +15,8 @@ import (
 	"github.com/krane/krane/internal/utils"
 )
 
-// GetSessions returns a list of user sessions. A session is an authenticated client with a valid access token
+// GetSessions returns a list of user sessions. A session is an authenticated client with a valid access token,
+// tokens are only returned when created so sessions are listed with the prefix of their token.
 func GetSessions(w http.ResponseWriter, _ *http.Request) {
 	sessions, err := session.GetAllSessions()
 	if err != nil {
@@ -28,7 +28,10 @@ func GetSessions(w http.ResponseWriter, _ *http.Request) {
 	return
 }
 
-// CreateSession returns a session with an active access token. Access tokens are useful for CI
+// CreateSession returns a session with an active access token, the token cannot be retrieved again.
+// Access tokens are useful for CI, sessions are granted the deployer role unless another role is provided (admin, deployer or viewer).
+// Sessions can be restricted to a comma separated list of deployment names or glob patterns (scope)
+// and expire after their time to live (ttl), ACCESS_TOKEN_TTL_MS unless a shorter ttl is provided.
 func CreateSession(w http.ResponseWriter, r *http.Request) {
 	user := utils.QueryParamOrDefault(r, "user", "")
 
@@ -42,23 +45,34 @@ func CreateSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := session.Token{SessionID: uuid.Generate().String()}
-	signedTkn, err := session.CreateSessionToken(auth.GetServerPrivateKey(), token)
+	role, err := session.ParseRole(utils.QueryParamOrDefault(r, "role", string(session.DeployerRole)))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	// a scope param without any pattern is rejected instead of creating an unrestricted session
+	scopeParams, scoped := r.URL.Query()["scope"]
+	scope, err := session.ParseScope(strings.Join(scopeParams, ","))
 	if err != nil {
-		logger.Errorf("unable to create session %v", err)
 		response.HTTPBad(w, err)
 		return
 	}
 
-	newSession := session.Session{
-		ID:        token.SessionID,
-		Token:     signedTkn,
-		ExpiresAt: utils.UnixToDate(utils.OneYear),
-		User:      strings.ToLower(user),
+	if scoped && scope.Unrestricted() {
+		response.HTTPBad(w, errors.New("scope cannot be empty, omit it to create an unrestricted session"))
+		return
 	}
 
-	if err := session.Save(newSession); err != nil {
-		logger.Errorf("unable to save session %v", err)
+	ttl, err := session.ParseTTL(utils.QueryParamOrDefault(r, "ttl", ""), session.AccessTokenTTL())
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	newSession, err := session.New(auth.GetServerPrivateKey(), strings.ToLower(user), role, scope, ttl)
+	if err != nil {
+		logger.Errorf("unable to create session %v", err)
 		response.HTTPBad(w, err)
 		return
 	}
@@ -67,6 +81,35 @@ func CreateSession(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// RefreshSession rotates the token of the current session before it expires. A new session is returned with the
+// same user, role and scope and the current token is revoked. The new session keeps the time to live of the current
+// session unless a shorter ttl is provided, sessions cannot be refreshed past the session max age (SESSION_MAX_AGE_MS).
+func RefreshSession(w http.ResponseWriter, r *http.Request) {
+	s := r.Context().Value("session").(session.Session)
+
+	// the new session cannot live longer than the current session did
+	max := s.Lifetime()
+	if max <= 0 {
+		max = session.LoginTTL()
+	}
+
+	ttl, err := session.ParseTTL(utils.QueryParamOrDefault(r, "ttl", ""), max)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	refreshed, err := session.Refresh(auth.GetServerPrivateKey(), s, ttl)
+	if err != nil {
+		logger.Errorf("unable to refresh session %v", err)
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, refreshed)
+	return
+}
+
 // DeleteSession will delete a session revoking any further request using that session token
 func DeleteSession(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
@@ -77,7 +120,8 @@ func DeleteSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !session.Exist(sessionID) {
+	// expired sessions not yet swept can still be deleted
+	if _, err := session.GetSessionByID(sessionID); err != nil {
 		response.HTTPBad(w, fmt.Errorf("sessions with id %s does not exist", sessionID))
 		return
 	}
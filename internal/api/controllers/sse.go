@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+)
+
+// StreamContainerLogs streams container logs to the client as Server-Sent Events
+func StreamContainerLogs(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	container := params["container"]
+
+	client, err := deployment.NewSSEClient(w, r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToContainerLogs(client, container)
+	return
+}
+
+// StreamDeploymentLogs streams deployment logs to the client as Server-Sent Events
+func StreamDeploymentLogs(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	client, err := deployment.NewSSEClient(w, r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToDeploymentLogs(client, deploymentName)
+	return
+}
+
+// StreamDeploymentEvents streams deployment events to the client as Server-Sent Events. Recorded events are
+// replayed the same way as for websocket clients, the id of every event is its sequence number.
+func StreamDeploymentEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	replay, err := eventReplayFromRequest(r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	client, err := deployment.NewSSEClient(w, r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToDeploymentEvents(client, deploymentName, replay)
+	return
+}
+
+// StreamEvents streams the events of every deployment to the client as Server-Sent Events
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	client, err := deployment.NewSSEClient(w, r)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.SubscribeToEvents(client, eventFilterFromRequest(r))
+	return
+}
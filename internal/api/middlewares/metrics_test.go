@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestCount(t *testing.T, route, method, code string) uint64 {
+	m := &dto.Metric{}
+	assert.Nil(t, requestDuration.WithLabelValues(route, method, code).(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsRecordsRequestsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/deployments/{deployment}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+
+	for _, deployment := range []string{"api", "web"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/deployments/"+deployment, nil))
+	}
+
+	assert.Equal(t, uint64(2), requestCount(t, "/deployments/{deployment}", http.MethodGet, "404"))
+}
+
+func TestMetricsRecordsHijackedConnections(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/ws/events", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ws/events")
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, uint64(1), requestCount(t, "/ws/events", http.MethodGet, "101"))
+}
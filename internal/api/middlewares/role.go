@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/session"
+)
+
+// RequireRole middleware rejecting requests from sessions not granted a role, the session
+// is expected in the request context (set by ValidateSessionMiddleware)
+func RequireRole(role session.Role) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, ok := r.Context().Value("session").(session.Session)
+			if !ok || !s.HasRole(role) {
+				response.HTTPForbidden(w, fmt.Errorf("%s role required", role))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/session"
+)
+
+func TestRequireRole(t *testing.T) {
+	handler := RequireRole(session.DeployerRole)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(s interface{}) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/deployments/api", nil)
+		if s != nil {
+			r = r.WithContext(context.WithValue(r.Context(), "session", s))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(session.Session{Role: session.AdminRole}).Code)
+	assert.Equal(t, http.StatusOK, serve(session.Session{Role: session.DeployerRole}).Code)
+
+	w := serve(session.Session{Role: session.ViewerRole})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "deployer role required", w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, serve(nil).Code)
+}
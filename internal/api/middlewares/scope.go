@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/session"
+)
+
+// RequireDeploymentScope middleware rejecting requests for a deployment outside of the session scope. The deployment
+// is read from the route, containers are resolved to the deployment they belong to. Routes listing resources across
+// deployments filter them by the session scope instead.
+func RequireDeploymentScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := r.Context().Value("session").(session.Session)
+		if !ok {
+			response.HTTPForbidden(w, errors.New("session required"))
+			return
+		}
+
+		if s.Scope.Unrestricted() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		params := mux.Vars(r)
+		if name, ok := params["deployment"]; ok && !s.Scope.Allows(name) {
+			response.HTTPForbidden(w, fmt.Errorf("deployment %s is outside of the session scope", name))
+			return
+		}
+
+		if container, ok := params["container"]; ok {
+			name, err := deployment.GetContainerDeployment(container)
+			if err != nil || !s.Scope.Allows(name) {
+				response.HTTPForbidden(w, fmt.Errorf("container %s is outside of the session scope", container))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireUnrestrictedScope middleware rejecting requests from sessions restricted to some deployments
+func RequireUnrestrictedScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := r.Context().Value("session").(session.Session)
+		if !ok || !s.Scope.Unrestricted() {
+			response.HTTPForbidden(w, errors.New("session restricted to some deployments"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
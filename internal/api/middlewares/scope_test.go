@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/session"
+)
+
+func TestRequireDeploymentScope(t *testing.T) {
+	router := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Handle("/deployments/{deployment}", RequireDeploymentScope(http.HandlerFunc(ok)))
+	router.Handle("/deployments", RequireDeploymentScope(http.HandlerFunc(ok)))
+
+	serve := func(s session.Session, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), "session", s))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	scoped := session.Session{Scope: session.Scope{"payments-*"}}
+	assert.Equal(t, http.StatusOK, serve(scoped, "/deployments/payments-api").Code)
+	assert.Equal(t, http.StatusOK, serve(scoped, "/deployments").Code)
+
+	w := serve(scoped, "/deployments/web")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "deployment web is outside of the session scope", w.Body.String())
+
+	assert.Equal(t, http.StatusOK, serve(session.Session{}, "/deployments/web").Code)
+}
+
+func TestRequireUnrestrictedScope(t *testing.T) {
+	handler := RequireUnrestrictedScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(s session.Session) int {
+		r := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "session", s)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(session.Session{}))
+	assert.Equal(t, http.StatusForbidden, serve(session.Session{Scope: session.Scope{"api"}}))
+}
@@ -5,12 +5,16 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/dgrijalva/jwt-go"
+
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/auth"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/session"
 )
 
+var errSessionExpired = errors.New("session expired")
+
 // ValidateSessionMiddleware middleware to authenticate a client token against an active session
 func ValidateSessionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +34,11 @@ func ValidateSessionMiddleware(next http.Handler) http.Handler {
 		pk := auth.GetServerPrivateKey()
 		_, tknValue := session.ParseTokenTypeAndValue(tkn)
 		decodedTkn, err := session.DecodeJWTToken(pk, tknValue)
+		if isExpiredTokenErr(err) {
+			response.HTTPUnauthorized(w, errSessionExpired)
+			r.Context().Done()
+			return
+		}
 		if err != nil {
 			logger.Infof("Unable to decode token %s", err.Error())
 			response.HTTPBad(w, err)
@@ -54,8 +63,34 @@ func ValidateSessionMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// only a hash of the session token is stored, the token must be the one the session was created with
+		if !s.MatchesToken(tknValue) {
+			logger.Info("Token does not match its session")
+			response.HTTPBad(w, errors.New("invalid token"))
+			r.Context().Done()
+			return
+		}
+
+		// expired sessions are rejected even before the sweeper deletes them
+		if s.IsExpired() {
+			response.HTTPUnauthorized(w, errSessionExpired)
+			r.Context().Done()
+			return
+		}
+
+		s, err = session.Touch(s)
+		if err != nil {
+			logger.Debugf("unable to record the use of session %s, %v", s.ID, err)
+		}
+
 		// add the session as part of the request context
 		ctx := context.WithValue(r.Context(), "session", s)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// isExpiredTokenErr returns true if a token was rejected because it expired
+func isExpiredTokenErr(err error) bool {
+	validationErr, ok := err.(*jwt.ValidationError)
+	return ok && validationErr.Errors&jwt.ValidationErrorExpired != 0
+}
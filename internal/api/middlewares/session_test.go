@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/session"
+	"github.com/krane/krane/internal/utils/test"
+)
+
+const signingKey = "something"
+
+func TestMain(m *testing.M) {
+	test.SetupDb()
+	os.Setenv(constants.EnvKranePrivateKey, signingKey)
+
+	code := m.Run()
+
+	os.Unsetenv(constants.EnvKranePrivateKey)
+	test.TeardownDb()
+	os.Exit(code)
+}
+
+func TestValidateSessionMiddleware(t *testing.T) {
+	handler := ValidateSessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/deployments", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	active, err := session.New(signingKey, "ci", session.ViewerRole, nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, serve(active.Token).Code)
+
+	// the use of the session is recorded
+	assert.NotEmpty(t, session.GetLastUsedAt(active.ID))
+
+	// another token for the same session is not the token the session was created with
+	otherTkn, err := session.CreateSessionToken(signingKey, session.Token{SessionID: active.ID}, time.Now().Add(2*time.Hour))
+	assert.Nil(t, err)
+	w := serve(otherTkn)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid token", w.Body.String())
+
+	// the token expired
+	expiredTkn, err := session.CreateSessionToken(signingKey, session.Token{SessionID: active.ID}, time.Now().Add(-time.Minute))
+	assert.Nil(t, err)
+	w = serve(expiredTkn)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "session expired", w.Body.String())
+
+	// the session expired while its token is still valid
+	expired := active
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	assert.Nil(t, session.Save(expired))
+	assert.Equal(t, http.StatusUnauthorized, serve(active.Token).Code)
+
+	// the session was revoked
+	assert.Nil(t, session.Delete(active.ID))
+	assert.Equal(t, http.StatusBadRequest, serve(active.Token).Code)
+}
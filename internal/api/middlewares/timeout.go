@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Timeout middleware responding with a 503 when a request isn't handled within the timeout. Realtime streams
+// must not be wrapped since the timeout response writer can neither be flushed nor hijacked.
+func Timeout(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, timeout, "request timed out")
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutRespondsWhenRequestTakesTooLong(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	router := mux.NewRouter()
+	router.Use(Timeout(10 * time.Millisecond))
+	router.HandleFunc("/deployments", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}).Methods(http.MethodGet)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/deployments", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
@@ -51,6 +51,14 @@ func HTTPBad(w http.ResponseWriter, err error) {
 	return
 }
 
+// HTTPUnauthorized writes http response code 401
+func HTTPUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
+
 // HTTPNotFound writes http response code 404
 func HTTPNotFound(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
@@ -58,3 +66,11 @@ func HTTPNotFound(w http.ResponseWriter, err error) {
 	_, _ = w.Write([]byte(err.Error()))
 	return
 }
+
+// HTTPForbidden writes http response code 403
+func HTTPForbidden(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
@@ -3,13 +3,29 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/docker/distribution/uuid"
 
 	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/store"
+	"github.com/krane/krane/internal/utils"
 )
 
+// prefix of the phrase signed by clients when authenticating, followed by the request id
+const phrasePrefix = "Krane authentication request id: "
+
+// default time a login request can be used to authenticate
+const defaultLoginRequestTTL = 5 * time.Minute
+
+// loginRequest is a pending login request stored until the client authenticates
+type loginRequest struct {
+	ID        string `json:"id"`
+	Phrase    string `json:"phrase"`
+	CreatedAt int64  `json:"created_at"` // unix time the request was created
+}
+
 // GetAuthenticationPhrase returns the generate phrase for a given request id
 func GetAuthenticationPhrase(requestID string) (string, error) {
 	bytes, err := store.Client().Get(constants.AuthenticationCollectionName, requestID)
@@ -21,25 +37,84 @@ func GetAuthenticationPhrase(requestID string) (string, error) {
 		return "", errors.New("invalid request id")
 	}
 
-	return string(bytes), nil
+	var req loginRequest
+	if err := store.Deserialize(bytes, &req); err != nil {
+		return "", errors.New("invalid request id")
+	}
+
+	if req.isStale(time.Now(), LoginRequestTTL()) {
+		return "", errors.New("login request expired")
+	}
+
+	return req.Phrase, nil
 }
 
 // CreateAuthenticationPhrase returns a request id (uuid) and a phrase used by the client for authentication
 func CreateAuthenticationPhrase() (string, string, error) {
 	reqID := uuid.Generate().String()
-	phrase := []byte(fmt.Sprintf("Krane authentication request id: %s", reqID))
+	req := loginRequest{
+		ID:        reqID,
+		Phrase:    fmt.Sprintf("%s%s", phrasePrefix, reqID),
+		CreatedAt: time.Now().Unix(),
+	}
+
+	bytes, err := store.Serialize(req)
+	if err != nil {
+		return "", "", err
+	}
 
-	if err := store.Client().Put(constants.AuthenticationCollectionName, reqID, phrase); err != nil {
+	if err := store.Client().Put(constants.AuthenticationCollectionName, reqID, bytes); err != nil {
 		if err := store.Client().Remove(constants.AuthenticationCollectionName, reqID); err != nil {
 			return "", "", err
 		}
 		return "", "", err
 	}
 
-	return reqID, string(phrase), nil
+	return reqID, req.Phrase, nil
 }
 
 // RevokeAuthenticationRequest removes the request from the authentication collection
 func RevokeAuthenticationRequest(requestID string) error {
 	return store.Client().Remove(constants.AuthenticationCollectionName, requestID)
 }
+
+// DeleteStaleAuthenticationRequests removes the login requests older than the login request time to live
+// and returns the amount of requests removed
+func DeleteStaleAuthenticationRequests() (int, error) {
+	all, err := store.Client().GetAll(constants.AuthenticationCollectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	ttl := LoginRequestTTL()
+
+	deleted := 0
+	for _, bytes := range all {
+		var req loginRequest
+		if err := store.Deserialize(bytes, &req); err != nil {
+			// requests stored before they had a creation date only contain their phrase
+			req = loginRequest{ID: strings.TrimPrefix(string(bytes), phrasePrefix)}
+		}
+
+		if req.ID == "" || !req.isStale(now, ttl) {
+			continue
+		}
+
+		if err := RevokeAuthenticationRequest(req.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// LoginRequestTTL returns the time a login request can be used to authenticate (LOGIN_REQUEST_TTL_MS)
+func LoginRequestTTL() time.Duration {
+	return utils.DurationEnvMs(constants.EnvLoginRequestTTLMs, defaultLoginRequestTTL)
+}
+
+func (r loginRequest) isStale(now time.Time, ttl time.Duration) bool {
+	return now.Sub(time.Unix(r.CreatedAt, 0)) > ttl
+}
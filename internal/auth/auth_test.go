@@ -3,9 +3,12 @@ package auth
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
 	"github.com/krane/krane/internal/utils/test"
 )
 
@@ -51,3 +54,39 @@ func TestRevokeAuthenticationRequest(t *testing.T) {
 	assert.Error(t, err, "invalid request id")
 	assert.Empty(t, phrase)
 }
+
+func TestGetAuthenticationPhraseReturnsErrWhenRequestExpired(t *testing.T) {
+	reqID, _, err := CreateAuthenticationPhrase()
+	assert.Nil(t, err)
+
+	os.Setenv(constants.EnvLoginRequestTTLMs, "1")
+	defer os.Unsetenv(constants.EnvLoginRequestTTLMs)
+	time.Sleep(time.Second)
+
+	phrase, err := GetAuthenticationPhrase(reqID)
+	assert.EqualError(t, err, "login request expired")
+	assert.Empty(t, phrase)
+}
+
+func TestDeleteStaleAuthenticationRequests(t *testing.T) {
+	stale := loginRequest{ID: "stale", Phrase: phrasePrefix + "stale", CreatedAt: time.Now().Add(-time.Hour).Unix()}
+	bytes, _ := store.Serialize(stale)
+	assert.Nil(t, store.Client().Put(constants.AuthenticationCollectionName, stale.ID, bytes))
+
+	// requests stored before they had a creation date are stale
+	assert.Nil(t, store.Client().Put(constants.AuthenticationCollectionName, "legacy", []byte(phrasePrefix+"legacy")))
+
+	reqID, _, err := CreateAuthenticationPhrase()
+	assert.Nil(t, err)
+
+	deleted, err := DeleteStaleAuthenticationRequests()
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, deleted, 2)
+
+	_, err = GetAuthenticationPhrase(stale.ID)
+	assert.Error(t, err)
+	_, err = GetAuthenticationPhrase("legacy")
+	assert.Error(t, err)
+	_, err = GetAuthenticationPhrase(reqID)
+	assert.Nil(t, err)
+}
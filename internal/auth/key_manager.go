@@ -7,6 +7,7 @@ import (
 
 	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/session"
 )
 
 // GetServerPrivateKey returns the private key for the Krane server
@@ -15,7 +16,7 @@ func GetServerPrivateKey() string {
 }
 
 // GetServerAuthorizeKeys returns the authorized keys on the host running Krane
-func GetServerAuthorizeKeys() []string {
+func GetServerAuthorizeKeys() []session.AuthorizedKey {
 	homeDir, _ := os.UserHomeDir()
 	authKeysDir := homeDir + "/.ssh/authorized_keys"
 
@@ -24,25 +25,56 @@ func GetServerAuthorizeKeys() []string {
 	bytes, err := ioutil.ReadFile(authKeysDir)
 	if err != nil {
 		logger.Debugf("unable to read auth keys from %s, %s", authKeysDir, err.Error())
-		return make([]string, 0)
+		return make([]session.AuthorizedKey, 0)
 	}
 
-	// remove trailing new line from authorized_keys file
-	authKeys := strings.Replace(string(bytes), "\n\n", "\n", -1)
+	return parseAuthorizedKeys(string(bytes))
+}
 
-	// split the keys on every new line
-	return split(authKeys)
+// GetServerAuthorizeKeysFor returns the authorized keys which can authenticate a client connecting from an address,
+// keys past their expiry-time or restricted to other addresses with from= are left out
+func GetServerAuthorizeKeysFor(addr string) []session.AuthorizedKey {
+	return usableAuthorizedKeys(GetServerAuthorizeKeys(), addr)
 }
 
-func split(s string) []string {
-	resp := make([]string, 0)
+// usableAuthorizedKeys filters out the keys which cannot authenticate a client connecting from an address
+func usableAuthorizedKeys(keys []session.AuthorizedKey, addr string) []session.AuthorizedKey {
+	usable := make([]session.AuthorizedKey, 0)
+	for _, key := range keys {
+		if key.IsExpired() {
+			logger.Debugf("skipping authorized key %s, expired at %s", key.Comment, key.ExpiresAt.String())
+			continue
+		}
 
-	all := strings.Split(s, "\n")
-	for _, i := range all {
-		if i != "" {
-			resp = append(resp, i)
+		if !key.AllowsAddress(addr) {
+			logger.Debugf("skipping authorized key %s, not allowed from %s", key.Comment, addr)
+			continue
 		}
+
+		usable = append(usable, key)
+	}
+	return usable
+}
+
+// parseAuthorizedKeys parses the entries of an authorized_keys file, blank lines, comments, entries that are
+// not rsa, ecdsa or ed25519 keys and entries with options Krane cannot honour (ie. command=) are skipped
+func parseAuthorizedKeys(s string) []session.AuthorizedKey {
+	keys := make([]session.AuthorizedKey, 0)
+
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, err := session.ParseAuthorizedKey(line)
+		if err != nil {
+			logger.Warnf("skipping authorized key on line %d, %v", i+1, err)
+			continue
+		}
+
+		keys = append(keys, key)
 	}
 
-	return resp
+	return keys
 }
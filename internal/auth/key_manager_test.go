@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAuthorizedKeys(t *testing.T) {
+	authorizedKeys := `# team keys
+ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHz6ixfJDdpdVrJkPEG+8H38TJtM2Y6vCNOp2dZ8MZGV alice@example.com
+
+no-pty,from="10.0.0.0/8,!10.0.0.1",expiry-time="20990101" ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEh5m4nWNdXltq+MQpPsFiJBXMzp6KgMbPO9v9pls7zwPx8J8c2xThoztH8FjZCS0n01j6h6V6qq5cr1gBCZ1SE= bob laptop
+ssh-rsa invalid carol@example.com
+command="echo hi" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHz6ixfJDdpdVrJkPEG+8H38TJtM2Y6vCNOp2dZ8MZGV dave@example.com
+`
+
+	keys := parseAuthorizedKeys(authorizedKeys)
+	assert.Len(t, keys, 2)
+
+	assert.Equal(t, "ssh-ed25519", keys[0].Type)
+	assert.Equal(t, "alice@example.com", keys[0].Comment)
+	assert.Empty(t, keys[0].Options)
+
+	assert.Equal(t, "ecdsa-sha2-nistp256", keys[1].Type)
+	assert.Equal(t, "bob laptop", keys[1].Comment)
+	assert.Equal(t, []string{"no-pty", `from="10.0.0.0/8,!10.0.0.1"`, `expiry-time="20990101"`}, keys[1].Options)
+	assert.Equal(t, []string{"10.0.0.0/8", "!10.0.0.1"}, keys[1].From)
+	assert.Equal(t, 2099, keys[1].ExpiresAt.Year())
+}
+
+func TestUsableAuthorizedKeys(t *testing.T) {
+	authorizedKeys := `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHz6ixfJDdpdVrJkPEG+8H38TJtM2Y6vCNOp2dZ8MZGV alice@example.com
+from="10.0.0.*" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHz6ixfJDdpdVrJkPEG+8H38TJtM2Y6vCNOp2dZ8MZGV bob@example.com
+expiry-time="20200101" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHz6ixfJDdpdVrJkPEG+8H38TJtM2Y6vCNOp2dZ8MZGV carol@example.com
+`
+	keys := parseAuthorizedKeys(authorizedKeys)
+	assert.Len(t, keys, 3)
+	assert.True(t, keys[2].ExpiresAt.Before(time.Now()))
+
+	usable := usableAuthorizedKeys(keys, "10.0.0.7")
+	assert.Len(t, usable, 2)
+	assert.Equal(t, "alice@example.com", usable[0].Comment)
+	assert.Equal(t, "bob@example.com", usable[1].Comment)
+
+	usable = usableAuthorizedKeys(keys, "192.168.0.7")
+	assert.Len(t, usable, 1)
+	assert.Equal(t, "alice@example.com", usable[0].Comment)
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/session"
+)
+
+// default interval at which the sweeper runs when the configured interval is invalid
+const defaultSweepInterval = 5 * time.Minute
+
+// Sweeper deletes expired sessions and stale login requests on an interval
+type Sweeper struct {
+	interval time.Duration
+}
+
+// NewSweeper returns a new sweeper running on an interval, invalid intervals fall back to 5 minutes
+func NewSweeper(interval_ms string) Sweeper {
+	interval, err := time.ParseDuration(interval_ms + "ms")
+	if err != nil || interval <= 0 {
+		logger.Warnf("Invalid session sweep interval %s, sweeping every %s", interval_ms, defaultSweepInterval.String())
+		interval = defaultSweepInterval
+	}
+	return Sweeper{interval: interval}
+}
+
+// Run starts the sweeper deleting expired sessions and login requests on an interval
+func (s Sweeper) Run() {
+	logger.Debug("Starting session sweeper")
+
+	for {
+		s.sweep()
+		<-time.After(s.interval)
+	}
+}
+
+func (s Sweeper) sweep() {
+	sessions, err := session.DeleteExpired()
+	if err != nil {
+		logger.Errorf("Session sweeper unable to delete expired sessions, %v", err)
+	}
+
+	requests, err := DeleteStaleAuthenticationRequests()
+	if err != nil {
+		logger.Errorf("Session sweeper unable to delete stale login requests, %v", err)
+	}
+
+	if sessions > 0 || requests > 0 {
+		logger.Debugf("Deleted %d expired sessions and %d stale login requests", sessions, requests)
+	}
+}
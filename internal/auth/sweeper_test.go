@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSweeperFallsBackToTheDefaultInterval(t *testing.T) {
+	assert.Equal(t, time.Minute, NewSweeper("60000").interval)
+	assert.Equal(t, defaultSweepInterval, NewSweeper("0").interval)
+	assert.Equal(t, defaultSweepInterval, NewSweeper("").interval)
+	assert.Equal(t, defaultSweepInterval, NewSweeper("five").interval)
+}
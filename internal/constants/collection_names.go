@@ -1,9 +1,14 @@
 package constants
 
 const (
-	AuthenticationCollectionName = "authentication"
-	DeploymentsCollectionName    = "deployments"
-	JobsCollectionName           = "jobs"
-	SessionsCollectionName       = "sessions"
-	SecretsCollectionName        = "secrets"
+	AuthenticationCollectionName   = "authentication"
+	DeploymentsCollectionName      = "deployments"
+	JobsCollectionName             = "jobs"
+	SessionsCollectionName         = "sessions"
+	SessionsLastUsedCollectionName = "sessions_last_used"
+	SecretsCollectionName          = "secrets"
+	RevisionsCollectionName        = "revisions"
+	JobQueueCollectionName         = "job_queue"
+	EventsCollectionName           = "events"
+	StoppedCollectionName          = "stopped_deployments"
 )
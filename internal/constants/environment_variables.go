@@ -10,7 +10,19 @@ const (
 	EnvJobQueueSize            = "JOB_QUEUE_SIZE"
 	EnvJobMaxRetryPolicy       = "JOB_MAX_RETRY_POLICY"
 	EnvDeploymentRetryPolicy   = "DEPLOYMENT_RETRY_POLICY"
+	EnvJobRetryBackoffMs       = "JOB_RETRY_BACKOFF_MS"
+	EnvJobRetryMaxBackoffMs    = "JOB_RETRY_MAX_BACKOFF_MS"
+	EnvJobTimeoutMs            = "JOB_TIMEOUT_MS"
 	EnvSchedulerIntervalMs     = "SCHEDULER_INTERVAL_MS"
+	EnvSchedulerCooldownMs     = "SCHEDULER_COOLDOWN_MS"
+	EnvLogForwarderIntervalMs  = "LOG_FORWARDER_INTERVAL_MS"
+	EnvLogSinkDir              = "LOG_SINK_DIR"
+	EnvEventRetentionMs        = "EVENT_RETENTION_MS"
+	EnvSessionTTLMs            = "SESSION_TTL_MS"
+	EnvAccessTokenTTLMs        = "ACCESS_TOKEN_TTL_MS"
+	EnvSessionMaxAgeMs         = "SESSION_MAX_AGE_MS"
+	EnvLoginRequestTTLMs       = "LOGIN_REQUEST_TTL_MS"
+	EnvSessionSweepIntervalMs  = "SESSION_SWEEP_INTERVAL_MS"
 	EnvDockerBasicAuthUsername = "DOCKER_BASIC_AUTH_USERNAME"
 	EnvDockerBasicAuthPassword = "DOCKER_BASIC_AUTH_PASSWORD"
 	EnvProxyEnabled            = "PROXY_ENABLED"
@@ -19,36 +19,63 @@ import (
 
 // Config represents a deployment configuration
 type Config struct {
-	Name       string            `json:"name" binding:"required"`  // deployment name
-	Image      string            `json:"image" binding:"required"` // container image
-	Registry   string            `json:"registry"`                 // container registry
-	Tag        string            `json:"tag"`                      // container image tag
-	Alias      []string          `json:"alias"`                    // custom domain aliases (my-app.example.com or my-app.localhost)
-	Env        map[string]string `json:"env"`                      // deployment environment variables
-	Secrets    map[string]string `json:"secrets"`                  // deployment secrets resolved as environment variables
-	Labels     map[string]string `json:"labels"`                   // container labels
-	Ports      map[string]string `json:"ports"`                    // container ports to expose from the container to the host
-	TargetPort string            `json:"target_port"`              // the target port to load-balance request through
-	Volumes    map[string]string `json:"volumes"`                  // container volumes
-	Command    string            `json:"command"`                  // container start command
-	Entrypoint string            `json:"entrypoint"`               // container entrypoint
-	Scale      int               `json:"scale"`                    // number of containers to create for the deployment
-	Secure     bool              `json:"secure"`                   // enable/disable secure communication over HTTPS/TLS w/ auto generated certs
-	Internal   bool              `json:"internal"`                 // whether a deployment is internal (ie. krane-proxy)
-	RateLimit  uint              `json:"rate_limit"`               // requests per second for a given deployment (default 0, which means no rate limit)
+	Name          string            `json:"name" binding:"required"`  // deployment name
+	Image         string            `json:"image" binding:"required"` // container image
+	Registry      string            `json:"registry"`                 // container registry
+	Tag           string            `json:"tag"`                      // container image tag
+	Alias         []string          `json:"alias"`                    // custom domain aliases (my-app.example.com or my-app.localhost)
+	Env           map[string]string `json:"env"`                      // deployment environment variables
+	Secrets       map[string]string `json:"secrets"`                  // deployment secrets resolved as environment variables
+	Labels        map[string]string `json:"labels"`                   // container labels
+	Ports         map[string]string `json:"ports"`                    // container ports to expose from the container to the host
+	TargetPort    string            `json:"target_port"`              // the target port to load-balance request through
+	Volumes       map[string]string `json:"volumes"`                  // container volumes
+	Command       string            `json:"command"`                  // container start command
+	Entrypoint    string            `json:"entrypoint"`               // container entrypoint
+	Scale         int               `json:"scale"`                    // number of containers to create for the deployment
+	Secure        bool              `json:"secure"`                   // enable/disable secure communication over HTTPS/TLS w/ auto generated certs
+	Internal      bool              `json:"internal"`                 // whether a deployment is internal (ie. krane-proxy)
+	RateLimit     uint              `json:"rate_limit"`               // requests per second for a given deployment (default 0, which means no rate limit)
+	Strategy      Strategy          `json:"strategy"`                 // how containers are replaced when running the deployment
+	HealthCheck   HealthCheck       `json:"health_check"`             // how containers are verified to be healthy
+	Resources     Resources         `json:"resources"`                // container cpu, memory and pids limits
+	RestartPolicy RestartPolicy     `json:"restart_policy"`           // how containers are restarted when they exit
+	StopTimeout   *int              `json:"stop_timeout"`             // seconds to wait for a container to stop before killing it (default 60)
+	StopSignal    string            `json:"stop_signal"`              // signal sent to stop a container (default SIGTERM)
+	LogSinks      []LogSink         `json:"log_sinks"`                // external destinations container logs are forwarded to
 }
 
-// SaveConfig a deployment configuration into the db
-func SaveConfig(config Config) error {
+// SaveConfig a deployment configuration into the db. Every saved configuration
+// is also stored as a new revision of the deployment attributed to the user saving it.
+func SaveConfig(config Config, user string) error {
 	config.applyDefaults()
 
+	// log sink headers left redacted keep their saved value
+	if previous, err := GetDeploymentConfig(config.Name); err == nil {
+		for i := range config.LogSinks {
+			if i < len(previous.LogSinks) {
+				config.LogSinks[i].restoreRedactedHeaders(previous.LogSinks[i])
+			}
+		}
+	}
+
 	if err := config.isValid(); err != nil {
 		logger.Errorf("deployment config is not valid %v", err)
 		return err
 	}
 
 	bytes, _ := config.Serialize()
-	return store.Client().Put(constants.DeploymentsCollectionName, config.Name, bytes)
+	if err := store.Client().Put(constants.DeploymentsCollectionName, config.Name, bytes); err != nil {
+		return err
+	}
+
+	// the revision is saved once the config is, a config saved without changes doesn't create a revision
+	if _, err := saveRevision(config, user); err != nil {
+		logger.Errorf("unable to save deployment config revision %v", err)
+		return err
+	}
+
+	return nil
 }
 
 // Serialize returns the bytes for a deployment config
@@ -97,6 +124,17 @@ func (config *Config) applyDefaults() {
 		config.Tag = "latest"
 	}
 
+	if config.LogSinks == nil {
+		config.LogSinks = make([]LogSink, 0)
+	}
+
+	for i := range config.LogSinks {
+		config.LogSinks[i].applyDefaults()
+	}
+
+	config.Strategy.applyDefaults()
+	config.HealthCheck.applyDefaults(config.defaultHealthCheckPort())
+
 	return
 }
 
@@ -111,9 +149,55 @@ func (config Config) isValid() error {
 		return errors.New("image required in deployment config")
 	}
 
+	if err := config.Strategy.isValid(); err != nil {
+		return fmt.Errorf("invalid strategy in deployment config, %v", err)
+	}
+
+	if err := config.HealthCheck.isValid(); err != nil {
+		return fmt.Errorf("invalid health check in deployment config, %v", err)
+	}
+
+	if err := config.Resources.isValid(); err != nil {
+		return fmt.Errorf("invalid resources in deployment config, %v", err)
+	}
+
+	if err := config.RestartPolicy.isValid(); err != nil {
+		return fmt.Errorf("invalid restart policy in deployment config, %v", err)
+	}
+
+	if config.StopTimeout != nil && *config.StopTimeout < 0 {
+		return errors.New("stop timeout cannot be negative in deployment config")
+	}
+
+	if err := isValidStopSignal(config.StopSignal); err != nil {
+		return fmt.Errorf("invalid stop signal %s in deployment config", config.StopSignal)
+	}
+
+	for _, sink := range config.LogSinks {
+		if err := sink.isValid(); err != nil {
+			return fmt.Errorf("invalid log sink in deployment config, %v", err)
+		}
+	}
+
 	return nil
 }
 
+// defaultHealthCheckPort returns the container port probed by health checks when no port is set.
+// This is the target port or the container port when the deployment only exposes a single port.
+func (config Config) defaultHealthCheckPort() string {
+	if config.TargetPort != "" {
+		return config.TargetPort
+	}
+
+	if len(config.Ports) == 1 {
+		for _, containerPort := range config.Ports {
+			return containerPort
+		}
+	}
+
+	return ""
+}
+
 // isValidName return if a deployment name is valid or not
 func (config Config) isValidName() bool {
 	if len(config.Name) > 50 {
@@ -175,6 +259,17 @@ func DeleteConfig(deployment string) error {
 	return store.Client().Remove(constants.DeploymentsCollectionName, deployment)
 }
 
+// Redacted returns a copy of a config with the values of its log sink headers masked, configs
+// returned by the api are redacted since log sink headers usually hold credentials
+func (config Config) Redacted() Config {
+	sinks := make([]LogSink, 0, len(config.LogSinks))
+	for _, sink := range config.LogSinks {
+		sinks = append(sinks, sink.Redacted())
+	}
+	config.LogSinks = sinks
+	return config
+}
+
 // Empty returns true if a config has not defined a deployment name or image
 func (config Config) Empty() bool {
 	return config.Name == "" || config.Image == ""
@@ -211,6 +306,11 @@ func (config Config) DockerConfig() docker.DockerConfig {
 		Env:           config.DockerEnvs(),
 		Command:       command,
 		Entrypoint:    entrypoint,
+		HealthCheck:   config.HealthCheck.DockerHealthConfig(),
+		Resources:     config.Resources.DockerResources(),
+		RestartPolicy: config.RestartPolicy.DockerRestartPolicy(),
+		StopTimeout:   config.StopTimeout,
+		StopSignal:    config.StopSignal,
 	}
 }
 
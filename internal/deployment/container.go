@@ -25,6 +25,11 @@ type KraneContainer struct {
 	Volumes    []Volume          `json:"volumes"`
 	Command    []string          `json:"command"`
 	Entrypoint []string          `json:"entrypoint"`
+
+	Resources     Resources     `json:"resources"`
+	RestartPolicy RestartPolicy `json:"restart_policy"`
+	StopTimeout   *int          `json:"stop_timeout"`
+	StopSignal    string        `json:"stop_signal"`
 }
 
 // ContainerState represents the state of a Krane container
@@ -52,10 +57,7 @@ const (
 )
 
 // ContainerCreate creates a docker container from a deployment config
-func ContainerCreate(config Config) (KraneContainer, error) {
-	ctx := context.Background()
-	defer ctx.Done()
-
+func ContainerCreate(ctx context.Context, config Config) (KraneContainer, error) {
 	mappedConfig := config.DockerConfig()
 	body, err := docker.GetClient().CreateContainer(ctx, mappedConfig)
 	if err != nil {
@@ -73,26 +75,22 @@ func ContainerCreate(config Config) (KraneContainer, error) {
 }
 
 // Start starts a Krane managed Docker Container
-func (c KraneContainer) Start() error {
-	ctx := context.Background()
-	defer ctx.Done()
-
+func (c KraneContainer) Start(ctx context.Context) error {
 	return docker.GetClient().StartContainer(ctx, c.ID)
 }
 
 // Stops stops a Krane managed Docker Container
-func (c KraneContainer) Stop() error {
-	ctx := context.Background()
-	defer ctx.Done()
+func (c KraneContainer) Stop(ctx context.Context) error {
+	timeout := docker.DefaultStopTimeout
+	if c.StopTimeout != nil {
+		timeout = time.Duration(*c.StopTimeout) * time.Second
+	}
 
-	return docker.GetClient().StopContainer(ctx, c.ID)
+	return docker.GetClient().StopContainer(ctx, c.ID, timeout)
 }
 
 // Remove removes a Krane managed Docker container
-func (c KraneContainer) Remove() error {
-	ctx := context.Background()
-	defer ctx.Done()
-
+func (c KraneContainer) Remove(ctx context.Context) error {
 	return docker.GetClient().RemoveContainer(ctx, c.ID, true)
 }
 
@@ -106,6 +104,16 @@ func fromDockerContainerToKcontainer(container types.ContainerJSON) KraneContain
 	ports := fromPortMapToPortList(container.NetworkSettings.Ports)
 	volumes := fromMountPointToVolumeList(container.Mounts)
 
+	var resources Resources
+	var restartPolicy RestartPolicy
+	if container.HostConfig != nil {
+		resources = fromDockerResourcesToResources(container.HostConfig.Resources)
+		restartPolicy = RestartPolicy{
+			Name:              RestartPolicyName(container.HostConfig.RestartPolicy.Name),
+			MaximumRetryCount: container.HostConfig.RestartPolicy.MaximumRetryCount,
+		}
+	}
+
 	return KraneContainer{
 		ID:         container.ID,
 		Deployment: container.Config.Labels[docker.ContainerDeploymentLabel],
@@ -120,6 +128,11 @@ func fromDockerContainerToKcontainer(container types.ContainerJSON) KraneContain
 		Volumes:    volumes,
 		Command:    container.Config.Cmd,
 		Entrypoint: container.Config.Entrypoint,
+
+		Resources:     resources,
+		RestartPolicy: restartPolicy,
+		StopTimeout:   container.Config.StopTimeout,
+		StopSignal:    container.Config.StopSignal,
 	}
 }
 
@@ -167,6 +180,20 @@ func isKraneManagedContainer(container types.ContainerJSON) bool {
 	return len(container.Config.Labels[docker.ContainerDeploymentLabel]) > 0
 }
 
+// GetContainerDeployment returns the name of the deployment a container belongs to, empty if the container is not managed by Krane
+func GetContainerDeployment(containerID string) (string, error) {
+	container, err := docker.GetClient().GetOneContainer(context.Background(), containerID)
+	if err != nil {
+		return "", err
+	}
+
+	if container.Config == nil {
+		return "", nil
+	}
+
+	return container.Config.Labels[docker.ContainerDeploymentLabel], nil
+}
+
 // GetContainersByDeployment get containers filtered by deployment
 func GetContainersByDeployment(deployment string) ([]KraneContainer, error) {
 	allContainers, err := GetContainers()
@@ -185,35 +212,6 @@ func GetContainersByDeployment(deployment string) ([]KraneContainer, error) {
 	return containers, nil
 }
 
-// RetriableContainersHealthCheck returns an error if a container is considered unhealthy
-func RetriableContainersHealthCheck(containers []KraneContainer, retries int) error {
-	for _, c := range containers {
-		for i := 0; i <= retries; i++ {
-			expBackOff := time.Duration(10 * i)
-			time.Sleep(expBackOff * time.Second)
-
-			isRunning, err := c.Running()
-			if err != nil {
-				if i == retries {
-					return fmt.Errorf("container is not healthy %v", err)
-				}
-				continue
-			}
-
-			if !isRunning {
-				if i == retries {
-					return fmt.Errorf("container is not healthy %v", err)
-				}
-				continue
-			}
-
-			// if reached here container in a running state
-			break
-		}
-	}
-	return nil
-}
-
 // Running returns whether a container is in a running state
 func (c KraneContainer) Running() (bool, error) {
 	ctx := context.Background()
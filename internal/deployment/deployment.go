@@ -1,12 +1,12 @@
 package deployment
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/docker/distribution/uuid"
 
 	"github.com/krane/krane/internal/constants"
-	"github.com/krane/krane/internal/docker"
 	"github.com/krane/krane/internal/job"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/utils"
@@ -17,6 +17,13 @@ type Deployment struct {
 	Config     Config           `json:"config"`
 	Containers []KraneContainer `json:"containers"`
 	Jobs       []job.Job        `json:"jobs"`
+	Stopped    bool             `json:"stopped"` // containers were stopped on purpose and are not reconciled
+}
+
+// Redacted returns a copy of a deployment with its configuration redacted
+func (d Deployment) Redacted() Deployment {
+	d.Config = d.Config.Redacted()
+	return d
 }
 
 // Exist returns true if a deployment exist, false otherwise
@@ -54,6 +61,7 @@ func GetDeployment(deployment string) (Deployment, error) {
 		Config:     config,
 		Containers: containers,
 		Jobs:       jobs,
+		Stopped:    IsStopped(deployment),
 	}, nil
 }
 
@@ -85,14 +93,19 @@ func Run(deployment string) error {
 		return err
 	}
 
+	return enqueue(runJob(config, uuid.Generate().String()))
+}
+
+// runJob returns the job which creates or re-creates the container resources for a deployment configuration
+func runJob(config Config, jobID string) job.Job {
 	type RunDeploymentJobArgs struct {
 		Config             Config
 		ContainersToRemove []KraneContainer
+		ContainersCreated  []KraneContainer
 	}
 
-	jobID := uuid.Generate().String()
 	e := createEventEmitter(config.Name, jobID)
-	go enqueue(job.Job{
+	return job.Job{
 		ID:          jobID,
 		Deployment:  config.Name,
 		Type:        string(RunDeploymentJobType),
@@ -100,115 +113,121 @@ func Run(deployment string) error {
 		Args: &RunDeploymentJobArgs{
 			Config:             config,
 			ContainersToRemove: []KraneContainer{},
+			ContainersCreated:  []KraneContainer{},
 		},
-		Setup: func(args interface{}) error {
+		Setup: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RunDeploymentJobArgs)
 			deploymentName := jobArgs.Config.Name
 
-			// ensure secrets collections
-			if err := CreateSecretsCollection(deploymentName); err != nil {
-				logger.Errorf("unable to create secrets collection %v", err)
+			return runPhase(ctx, e, SetupPhase, func() error {
+				// ensure secrets collections
+				if err := CreateSecretsCollection(deploymentName); err != nil {
+					logger.Errorf("unable to create secrets collection %v", err)
+					return err
+				}
+
+				// ensure jobs collections
+				if err := CreateJobsCollection(deploymentName); err != nil {
+					logger.Errorf("unable to create jobs collection %v", err)
+					return err
+				}
+
+				// get containers (if any) currently part of this deployment
+				containers, err := GetContainersByDeployment(deploymentName)
+				if err != nil {
+					logger.Errorf("unable to get containers %v", err)
+					return err
+				}
+
+				// update job arguments to process them for deletion later on
+				jobArgs.ContainersToRemove = containers
+
+				return nil
+			})
+		},
+		Run: func(ctx context.Context, args interface{}) error {
+			jobArgs := args.(*RunDeploymentJobArgs)
+			config := jobArgs.Config
+
+			if err := pullImage(ctx, e, config); err != nil {
 				return err
 			}
 
-			// ensure jobs collections
-			if err := CreateJobsCollection(deploymentName); err != nil {
-				logger.Errorf("unable to create jobs collection %v", err)
+			// rolling updates retire the old containers in batches as new ones become healthy
+			if config.Strategy.Type == RollingStrategy {
+				containersCreated, err := rollingUpdate(ctx, e, config, jobArgs.ContainersToRemove)
+				jobArgs.ContainersCreated = containersCreated
 				return err
 			}
 
-			// get containers (if any) currently part of this deployment
-			containers, err := GetContainersByDeployment(deploymentName)
+			containersCreated, err := createContainers(ctx, e, config, config.Scale)
+			jobArgs.ContainersCreated = containersCreated
 			if err != nil {
-				logger.Errorf("unable to get containers %v", err)
 				return err
 			}
 
-			// update job arguments to process them for deletion later on
-			jobArgs.ContainersToRemove = containers
+			if err := startContainers(ctx, e, containersCreated); err != nil {
+				return err
+			}
 
-			return nil
+			return healthCheck(ctx, e, config, containersCreated)
 		},
-		Run: func(args interface{}) error {
+		Finally: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RunDeploymentJobArgs)
-			config := jobArgs.Config
 
-			// pull image
-			logger.Debugf("Pulling image for deployment %s", config.Name)
-			pullImageReader, err := docker.GetClient().PullImage(config.Registry, config.Image, config.Tag)
-			if err != nil {
-				logger.Errorf("unable to pull image %v", err)
+			if err := removeContainers(ctx, e, TeardownPhase, jobArgs.ContainersToRemove); err != nil {
 				return err
 			}
-			e.emitStream(pullImageReader)
-
-			// create containers
-			containersCreated := make([]KraneContainer, 0)
-			for i := 0; i < config.Scale; i++ {
-				c, err := ContainerCreate(config)
-				if err != nil {
-					logger.Errorf("unable to create container %v", err)
-					return err
-				}
-				containersCreated = append(containersCreated, c)
-			}
-			logger.Debugf("%d/%d container(s) for deployment %s created", config.Scale, len(containersCreated), config.Name)
 
-			// start containers
-			containersStarted := make([]KraneContainer, 0)
-			for _, c := range containersCreated {
-				if err := c.Start(); err != nil {
-					logger.Errorf("unable to start container %v", err)
-					return err
-				}
-				containersStarted = append(containersStarted, c)
-			}
-			logger.Debugf("%d/%d container(s) for deployment %s started", len(containersStarted), len(containersCreated), config.Name)
-
-			// health check
-			retries := 10
-			if err := RetriableContainersHealthCheck(containersStarted, retries); err != nil {
-				logger.Errorf("containers did not pass health check %v", err)
+			// the containers of a previously stopped deployment are running again
+			if err := setStopped(jobArgs.Config.Name, false); err != nil {
+				logger.Errorf("unable to clear stopped state %v", err)
 				return err
 			}
-			logger.Debugf("Deployment %s health check complete", config.Name)
+
+			e.done()
 			return nil
 		},
-		Finally: func(args interface{}) error {
+		Rollback: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RunDeploymentJobArgs)
 
-			for _, c := range jobArgs.ContainersToRemove {
-				logger.Debugf("Removing container %s", c.Name)
-				err := c.Remove()
-				if err != nil {
-					logger.Errorf("unable to remove container %v", err)
-					return err
-				}
+			// tear down the containers created by the failed run keeping the previous containers serving
+			err := runPhase(ctx, e, RollbackPhase, func() error {
+				return rollback(ctx, e, jobArgs.ContainersCreated, jobArgs.ContainersToRemove)
+			})
+			if err != nil {
+				logger.Errorf("unable to rollback deployment %v", err)
+				return err
 			}
 
+			jobArgs.ContainersCreated = []KraneContainer{}
 			return nil
 		},
-	})
-
-	return nil
+	}
 }
 
 // Delete removes a deployments container resources and configuration.
 // Note: This will also remove any existing collections created for the deployment (Secrets, Jobs, Config etc...)
 func Delete(deployment string) error {
+	return enqueue(deleteJob(deployment, uuid.Generate().String()))
+}
+
+// deleteJob returns the job which removes the container resources, collections and configuration of a deployment
+func deleteJob(deployment string, jobID string) job.Job {
 	type DeleteDeploymentJobArgs struct {
 		Deployment string
 	}
 
-	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+	e := createEventEmitter(deployment, jobID)
+	return job.Job{
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(DeleteDeploymentJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
 		Args: DeleteDeploymentJobArgs{
 			Deployment: deployment,
 		},
-		Run: func(args interface{}) error {
+		Run: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(DeleteDeploymentJobArgs)
 			deploymentName := jobArgs.Deployment
 
@@ -219,65 +238,85 @@ func Delete(deployment string) error {
 				return err
 			}
 
-			// remove containers
-			for _, c := range containers {
-				if err := c.Remove(); err != nil {
-					logger.Errorf("unable to remove container %v", err)
-					return err
-				}
-			}
-			logger.Debugf("%d container(s) for deployment %s removed", len(containers), deploymentName)
-
-			return nil
+			return removeContainers(ctx, e, RemoveContainerPhase, containers)
 		},
-		Finally: func(args interface{}) error {
+		Finally: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(DeleteDeploymentJobArgs)
 			deploymentName := jobArgs.Deployment
 
-			// delete secrets collection
-			logger.Debugf("removing secrets collection for deployment %s", deploymentName)
-			if err := DeleteSecretsCollection(deploymentName); err != nil {
-				logger.Errorf("unable to remove secrets collection %v", err)
-				return err
-			}
+			err := runPhase(ctx, e, TeardownPhase, func() error {
+				// delete secrets collection
+				logger.Debugf("removing secrets collection for deployment %s", deploymentName)
+				if err := DeleteSecretsCollection(deploymentName); err != nil {
+					logger.Errorf("unable to remove secrets collection %v", err)
+					return err
+				}
+
+				// delete jobs collection
+				logger.Debugf("removing jobs collection for deployment %s", deploymentName)
+				if err := DeleteJobsCollection(deploymentName); err != nil {
+					logger.Errorf("unable to remove jobs collection %v", err)
+					return err
+				}
+
+				// delete revisions collection, deployments saved before revisions
+				// were introduced don't have one so failing to remove it is not fatal
+				logger.Debugf("removing revisions collection for deployment %s", deploymentName)
+				if err := DeleteRevisionsCollection(deploymentName); err != nil {
+					logger.Warnf("unable to remove revisions collection %v", err)
+				}
+
+				if err := setStopped(deploymentName, false); err != nil {
+					logger.Warnf("unable to clear stopped state %v", err)
+				}
 
-			// delete jobs collection
-			logger.Debugf("removing jobs collection for deployment %s", deploymentName)
-			if err := DeleteJobsCollection(deploymentName); err != nil {
-				logger.Errorf("unable to remove jobs collection %v", err)
+				// delete deployment configuration
+				logger.Debugf("removing config for deployment %s", deploymentName)
+				if err := DeleteConfig(deploymentName); err != nil {
+					logger.Errorf("unable to remove deployment configuration %v", err)
+					return err
+				}
+
+				return nil
+			})
+			if err != nil {
 				return err
 			}
+			e.done()
 
-			// delete deployment configuration
-			logger.Debugf("removing config for deployment %s", deploymentName)
-			if err := DeleteConfig(deploymentName); err != nil {
-				logger.Errorf("unable to remove deployment configuration %v", err)
-				return err
+			// delete recorded events last so the teardown events are delivered to subscribed clients
+			logger.Debugf("removing events collection for deployment %s", deploymentName)
+			if err := DeleteEventsCollection(deploymentName); err != nil {
+				logger.Warnf("unable to remove events collection %v", err)
 			}
 
 			return nil
 		},
-	})
-
-	return nil
+	}
 }
 
 // StartContainers starts current existing containers (if any) for a deployment
 // Note: this does not re-create container resources, only start existing ones
 func StartContainers(deployment string) error {
+	return enqueue(startContainersJob(deployment, uuid.Generate().String()))
+}
+
+// startContainersJob returns the job which starts the existing containers of a deployment
+func startContainersJob(deployment string, jobID string) job.Job {
 	type StartContainersJobArgs struct {
 		Deployment string
 	}
 
-	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+	e := createEventEmitter(deployment, jobID)
+	return job.Job{
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(StartContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
 		Args: StartContainersJobArgs{
 			Deployment: deployment,
 		},
-		Run: func(args interface{}) error {
+		Run: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(StartContainersJobArgs)
 			deploymentName := jobArgs.Deployment
 
@@ -292,38 +331,43 @@ func StartContainers(deployment string) error {
 				return fmt.Errorf("deployment %s has 0 containers to start", deploymentName)
 			}
 
-			// start containers
-			for _, c := range containers {
-				logger.Debugf("Starting container %s", c.Name)
-				if err := c.Start(); err != nil {
-					logger.Errorf("unable to start container %v", err)
-					return err
-				}
+			if err := startContainers(ctx, e, containers); err != nil {
+				return err
+			}
+
+			if err := setStopped(deploymentName, false); err != nil {
+				logger.Errorf("unable to clear stopped state %v", err)
+				return err
 			}
-			logger.Debugf("%d container(s) for deployment %s started", len(containers), deploymentName)
 
+			e.done()
 			return nil
 		},
-	})
-	return nil
+	}
 }
 
 // StopContainers stops current existing containers (if any) for a deployment
 // Note: this does not re-create container resources, only stop existing ones
 func StopContainers(deployment string) error {
+	return enqueue(stopContainersJob(deployment, uuid.Generate().String()))
+}
+
+// stopContainersJob returns the job which stops the existing containers of a deployment
+func stopContainersJob(deployment string, jobID string) job.Job {
 	type StopContainersJobArgs struct {
 		Deployment string
 	}
 
-	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+	e := createEventEmitter(deployment, jobID)
+	return job.Job{
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(StopContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
 		Args: StopContainersJobArgs{
 			Deployment: deployment,
 		},
-		Run: func(args interface{}) error {
+		Run: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(StopContainersJobArgs)
 			deploymentName := jobArgs.Deployment
 
@@ -338,20 +382,20 @@ func StopContainers(deployment string) error {
 				return fmt.Errorf("deployment %s has 0 containers to stop", deploymentName)
 			}
 
-			// stop containers
-			for _, c := range containers {
-				logger.Debugf("Stopping container %s", c.Name)
-				if err := c.Stop(); err != nil {
-					logger.Errorf("unable to stop container %v", err)
-					return err
-				}
+			if err := stopContainers(ctx, e, containers); err != nil {
+				return err
 			}
-			logger.Debugf("%d container(s) for deployment %s stopped", len(containers), deploymentName)
 
+			// the scheduler leaves stopped deployments alone instead of re-creating their containers
+			if err := setStopped(deploymentName, true); err != nil {
+				logger.Errorf("unable to save stopped state %v", err)
+				return err
+			}
+
+			e.done()
 			return nil
 		},
-	})
-	return nil
+	}
 }
 
 // RestartContainers will re-create container resources for a deployment
@@ -362,93 +406,101 @@ func RestartContainers(deployment string) error {
 		return fmt.Errorf("unable to get configuration for deployment %s", deployment)
 	}
 
+	return enqueue(restartContainersJob(config, uuid.Generate().String()))
+}
+
+// restartContainersJob returns the job which re-creates the container resources for a deployment configuration
+func restartContainersJob(config Config, jobID string) job.Job {
 	type RestartContainersJobArgs struct {
 		Config             Config
 		ContainersToRemove []KraneContainer
+		ContainersCreated  []KraneContainer
 	}
 
-	jobID := uuid.Generate().String()
 	e := createEventEmitter(config.Name, jobID)
-	go enqueue(job.Job{
+	return job.Job{
 		ID:          jobID,
-		Deployment:  deployment,
+		Deployment:  config.Name,
 		Type:        string(RestartContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
 		Args: &RestartContainersJobArgs{
 			ContainersToRemove: []KraneContainer{},
+			ContainersCreated:  []KraneContainer{},
 			Config:             config,
 		},
-		Setup: func(args interface{}) error {
+		Setup: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RestartContainersJobArgs)
 			deploymentName := jobArgs.Config.Name
 
-			// get current containers (if any) which will be removed after new containers are created
-			containers, err := GetContainersByDeployment(deploymentName)
-			if err != nil {
-				logger.Errorf("unable to get containers %v", err)
-				return err
-			}
+			return runPhase(ctx, e, SetupPhase, func() error {
+				// get current containers (if any) which will be removed after new containers are created
+				containers, err := GetContainersByDeployment(deploymentName)
+				if err != nil {
+					logger.Errorf("unable to get containers %v", err)
+					return err
+				}
 
-			jobArgs.ContainersToRemove = containers
-			return nil
+				jobArgs.ContainersToRemove = containers
+				return nil
+			})
 		},
-		Run: func(args interface{}) error {
+		Run: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RestartContainersJobArgs)
 			config := jobArgs.Config
 
-			// pull image
-			logger.Debugf("Pulling image for deployment %s", config.Name)
-			pullImageReader, err := docker.GetClient().PullImage(config.Registry, config.Image, config.Tag)
+			if err := pullImage(ctx, e, config); err != nil {
+				return err
+			}
+
+			// rolling updates retire the old containers in batches as new ones become healthy
+			if config.Strategy.Type == RollingStrategy {
+				containersCreated, err := rollingUpdate(ctx, e, config, jobArgs.ContainersToRemove)
+				jobArgs.ContainersCreated = containersCreated
+				return err
+			}
+
+			containersCreated, err := createContainers(ctx, e, config, config.Scale)
+			jobArgs.ContainersCreated = containersCreated
 			if err != nil {
-				logger.Errorf("unable to pull image %v", err)
 				return err
 			}
-			e.emitStream(pullImageReader)
 
-			// create containers
-			containersCreated := make([]KraneContainer, 0)
-			for i := 0; i < config.Scale; i++ {
-				c, err := ContainerCreate(config)
-				if err != nil {
-					logger.Errorf("unable to create container %v", err)
-					return err
-				}
-				containersCreated = append(containersCreated, c)
+			if err := startContainers(ctx, e, containersCreated); err != nil {
+				return err
 			}
-			logger.Debugf("%d/%d container(s) for deployment %s created", len(containersCreated), config.Scale, config.Name)
 
-			// start containers
-			containersStarted := make([]KraneContainer, 0)
-			for _, c := range containersCreated {
-				if err := c.Start(); err != nil {
-					logger.Errorf("unable to start container %v", err)
-					return err
-				}
-				containersStarted = append(containersStarted, c)
+			return healthCheck(ctx, e, config, containersCreated)
+		},
+		Finally: func(ctx context.Context, args interface{}) error {
+			jobArgs := args.(*RestartContainersJobArgs)
+
+			if err := removeContainers(ctx, e, TeardownPhase, jobArgs.ContainersToRemove); err != nil {
+				return err
 			}
-			logger.Debugf("%d/%d container(s) for deployment %s started", len(containersStarted), len(containersCreated), config.Name)
 
-			retries := 10
-			if err := RetriableContainersHealthCheck(containersStarted, retries); err != nil {
-				logger.Errorf("containers did not pass health check %v", err)
+			// the containers of a previously stopped deployment are running again
+			if err := setStopped(jobArgs.Config.Name, false); err != nil {
+				logger.Errorf("unable to clear stopped state %v", err)
 				return err
 			}
-			logger.Debugf("Deployment %s health check complete", config.Name)
 
+			e.done()
 			return nil
 		},
-		Finally: func(args interface{}) error {
+		Rollback: func(ctx context.Context, args interface{}) error {
 			jobArgs := args.(*RestartContainersJobArgs)
-			for _, c := range jobArgs.ContainersToRemove {
-				logger.Debugf("Removing container %s", c.Name)
-				if err := c.Remove(); err != nil {
-					logger.Errorf("unable to remove container %v", err)
-					return err
-				}
+
+			// tear down the containers created by the failed restart keeping the previous containers serving
+			err := runPhase(ctx, e, RollbackPhase, func() error {
+				return rollback(ctx, e, jobArgs.ContainersCreated, jobArgs.ContainersToRemove)
+			})
+			if err != nil {
+				logger.Errorf("unable to rollback deployment %v", err)
+				return err
 			}
 
+			jobArgs.ContainersCreated = []KraneContainer{}
 			return nil
 		},
-	})
-	return nil
+	}
 }
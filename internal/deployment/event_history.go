@@ -0,0 +1,205 @@
+package deployment
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// interval at which recorded events older than the event retention are pruned
+const eventPruneInterval = time.Hour
+
+// EventReplay represents the recorded events replayed to a client when subscribing to deployment events
+type EventReplay struct {
+	JobID        string // replay the events of a single job
+	FromSequence uint64 // replay the events starting at a sequence number
+}
+
+// empty returns true if no events should be replayed
+func (r EventReplay) empty() bool { return r.JobID == "" && r.FromSequence == 0 }
+
+// lastEventSequence is the sequence number of the last event recorded for each deployment.
+// Sequences are loaded from the store the first time an event is recorded for a deployment.
+var (
+	lastEventSequence   = make(map[string]uint64)
+	lastEventSequenceMu sync.Mutex
+)
+
+func loadEventSequence(deployment string) (uint64, bool) {
+	lastEventSequenceMu.Lock()
+	defer lastEventSequenceMu.Unlock()
+
+	sequence, ok := lastEventSequence[deployment]
+	return sequence, ok
+}
+
+func storeEventSequence(deployment string, sequence uint64) {
+	lastEventSequenceMu.Lock()
+	defer lastEventSequenceMu.Unlock()
+
+	lastEventSequence[deployment] = sequence
+}
+
+func forgetEventSequence(deployment string) {
+	lastEventSequenceMu.Lock()
+	defer lastEventSequenceMu.Unlock()
+
+	delete(lastEventSequence, deployment)
+}
+
+// recordEvent assigns the next sequence number to an event and stores it, the deployment events must be locked
+func recordEvent(event Event) (Event, error) {
+	sequence, ok := loadEventSequence(event.Deployment)
+	if !ok {
+		events, err := getEvents(event.Deployment, EventReplay{})
+		if err != nil {
+			return event, err
+		}
+
+		if len(events) > 0 {
+			sequence = events[len(events)-1].Sequence
+		}
+	}
+
+	event.Sequence = sequence + 1
+	storeEventSequence(event.Deployment, event.Sequence)
+
+	bytes, err := store.Serialize(event)
+	if err != nil {
+		return event, err
+	}
+
+	collection := getEventsCollectionName(event.Deployment)
+	return event, store.Client().Put(collection, formatEventKey(event.Sequence), bytes)
+}
+
+// getEvents returns the recorded events of a deployment matching a replay in ascending order,
+// an empty replay returns every recorded event
+func getEvents(deployment string, replay EventReplay) ([]Event, error) {
+	collection := getEventsCollectionName(deployment)
+	bytes, err := store.Client().GetInRange(collection, formatEventKey(replay.FromSequence), formatEventKey(math.MaxUint64))
+	if err != nil {
+		return make([]Event, 0), err
+	}
+
+	events := make([]Event, 0)
+	for _, b := range bytes {
+		var event Event
+		if err := store.Deserialize(b, &event); err != nil {
+			return make([]Event, 0), err
+		}
+
+		if replay.JobID != "" && event.JobID != replay.JobID {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// GetJobEvents returns the recorded events of a deployment job in the order they were emitted
+func GetJobEvents(deployment, jobID string) ([]Event, error) {
+	unlock := lockEvents(deployment)
+	defer unlock()
+
+	return getEvents(deployment, EventReplay{JobID: jobID})
+}
+
+// DeleteEventsCollection deletes the recorded events of a deployment
+func DeleteEventsCollection(deployment string) error {
+	unlock := lockEvents(deployment)
+	defer unlock()
+
+	forgetEventSequence(deployment)
+	return store.Client().DeleteCollection(getEventsCollectionName(deployment))
+}
+
+// EventPruner deletes the recorded events of every deployment once they are older than the event retention
+type EventPruner struct {
+	retention time.Duration
+}
+
+// NewEventPruner returns a new event pruner keeping recorded events for a retention
+func NewEventPruner(retention_ms string) EventPruner {
+	retention, _ := time.ParseDuration(retention_ms + "ms")
+	return EventPruner{retention: retention}
+}
+
+// Run starts the pruner deleting recorded events older than the retention every hour
+func (p EventPruner) Run() {
+	logger.Debug("Starting event pruner")
+
+	for {
+		p.prune()
+		<-time.After(eventPruneInterval)
+	}
+}
+
+func (p EventPruner) prune() {
+	if p.retention <= 0 {
+		return
+	}
+
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		logger.Errorf("Event pruner unable to get deployments, %v", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-p.retention)
+	for _, config := range configs {
+		pruned, err := pruneEvents(config.Name, cutoff)
+		if err != nil {
+			logger.Warnf("Unable to prune events for deployment %s, %v", config.Name, err)
+			continue
+		}
+
+		if pruned > 0 {
+			logger.Debugf("Pruned %d events for deployment %s", pruned, config.Name)
+		}
+	}
+}
+
+// pruneEvents deletes the recorded events of a deployment created before a cutoff and returns the amount
+// of events deleted. The last recorded event is always kept so sequences keep increasing after a restart.
+func pruneEvents(deployment string, cutoff time.Time) (int, error) {
+	events, err := getEvents(deployment, EventReplay{})
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0)
+	for i, event := range events {
+		if i == len(events)-1 {
+			break
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, event.CreatedAt)
+		if err != nil || !createdAt.Before(cutoff) {
+			continue
+		}
+		keys = append(keys, formatEventKey(event.Sequence))
+	}
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	return len(keys), store.Client().RemoveKeys(getEventsCollectionName(deployment), keys)
+}
+
+func formatEventKey(sequence uint64) string {
+	// zero padded so events are sorted by their sequence number in the store
+	return fmt.Sprintf("%020d", sequence)
+}
+
+func getEventsCollectionName(deployment string) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s", deployment, constants.EventsCollectionName))
+}
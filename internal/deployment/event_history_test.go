@@ -0,0 +1,158 @@
+package deployment
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/utils"
+)
+
+func TestRecordedEvents(t *testing.T) {
+	deployment := "krane-events-test"
+	defer DeleteEventsCollection(deployment)
+
+	first := createEventEmitter(deployment, "job-1")
+	first.Phase = PullImagePhase
+	first.emitEvent(PhaseStartedEvent, "PULL_IMAGE started")
+	first.emit("Pulling from biensupernice/krane")
+
+	second := createEventEmitter(deployment, "job-2")
+	second.emit("Deployment failed, rolling back 1 container(s)")
+
+	events, err := GetJobEvents(deployment, "job-1")
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint64(1), events[0].Sequence)
+	assert.Equal(t, PhaseStartedEvent, events[0].Type)
+	assert.Equal(t, PullImagePhase, events[0].Phase)
+	assert.Equal(t, uint64(2), events[1].Sequence)
+	assert.Equal(t, "Pulling from biensupernice/krane", events[1].Message)
+
+	events, err = getEvents(deployment, EventReplay{FromSequence: 2})
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "job-1", events[0].JobID)
+	assert.Equal(t, "job-2", events[1].JobID)
+
+	// sequences continue from the recorded events after a restart
+	forgetEventSequence(deployment)
+	second.emit("Deployment rolled back")
+
+	events, err = GetJobEvents(deployment, "job-2")
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint64(4), events[1].Sequence)
+
+	assert.Nil(t, DeleteEventsCollection(deployment))
+	events, err = getEvents(deployment, EventReplay{})
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSubscribeReplaysRecordedEvents(t *testing.T) {
+	deployment := "krane-events-replay-test"
+	defer DeleteEventsCollection(deployment)
+
+	e := createEventEmitter(deployment, "job-1")
+	e.emit("recorded before subscribing")
+	createEventEmitter(deployment, "job-2").emit("recorded for another job")
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := upgrader.Upgrade(w, r, nil)
+		assert.Nil(t, err)
+		SubscribeToDeploymentEvents(NewWebSocketClient(client), deployment, EventReplay{JobID: "job-1"})
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	read := func() Event {
+		_, bytes, err := client.ReadMessage()
+		assert.Nil(t, err)
+
+		var event Event
+		assert.Nil(t, json.Unmarshal(bytes, &event))
+		return event
+	}
+
+	replayed := read()
+	assert.Equal(t, uint64(1), replayed.Sequence)
+	assert.Equal(t, "recorded before subscribing", replayed.Message)
+
+	// wait for the subscription before emitting a live event
+	for {
+		if hub.count() == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.emit("emitted after subscribing")
+	live := read()
+	assert.Equal(t, uint64(3), live.Sequence)
+	assert.Equal(t, "emitted after subscribing", live.Message)
+}
+
+func TestPruneEvents(t *testing.T) {
+	deployment := "krane-events-prune-test"
+	defer DeleteEventsCollection(deployment)
+
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	unlock := lockEvents(deployment)
+	for _, createdAt := range []string{old, old, utils.UTCDateString(), old} {
+		_, err := recordEvent(Event{Deployment: deployment, Type: MessageEvent, CreatedAt: createdAt})
+		assert.Nil(t, err)
+	}
+	unlock()
+
+	pruned, err := pruneEvents(deployment, time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pruned)
+
+	// the last event is kept even when older than the cutoff
+	events, err := getEvents(deployment, EventReplay{})
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint64(3), events[0].Sequence)
+	assert.Equal(t, uint64(4), events[1].Sequence)
+}
+
+func TestEmitStreamCollapsesPullProgress(t *testing.T) {
+	deployment := "krane-events-pull-test"
+	defer DeleteEventsCollection(deployment)
+
+	s := hub.subscribe(EventFilter{Deployments: []string{deployment}}, deployment, EventReplay{})
+	defer hub.unsubscribe(s)
+
+	pull := strings.Join([]string{
+		`{"status":"Pulling from biensupernice/krane","id":"latest"}`,
+		`{"status":"Downloading","progress":"[=>   ] 1MB/10MB","id":"a1"}`,
+		`{"status":"Downloading","progress":"[==>  ] 2MB/10MB","id":"a1"}`,
+		`{"status":"Downloading","progress":"[===> ] 3MB/10MB","id":"a1"}`,
+		`{"status":"Download complete","id":"a1"}`,
+		`{"status":"Extracting","progress":"[=>   ] 1MB/10MB","id":"a1"}`,
+		`{"status":"Extracting","progress":"[==>  ] 2MB/10MB","id":"a1"}`,
+		`{"status":"Pull complete","id":"a1"}`,
+	}, "\n")
+
+	createEventEmitter(deployment, "job-1").emitStream(strings.NewReader(pull))
+
+	// every line is broadcast
+	assert.Len(t, s.events, 8)
+
+	events, err := getEvents(deployment, EventReplay{})
+	assert.Nil(t, err)
+	assert.Len(t, events, 5)
+	assert.Contains(t, events[1].Message, "1MB/10MB")
+	assert.Equal(t, `{"status":"Download complete","id":"a1"}`, events[2].Message)
+}
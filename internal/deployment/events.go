@@ -4,61 +4,74 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
-
-	"github.com/gorilla/websocket"
+	"strconv"
+	"time"
 
 	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
 )
 
 type EventEmitter struct {
 	Deployment string
 	JobID      string
 	Phase      Phase
-	Clients    []*websocket.Conn
 }
 
 type Event struct {
-	JobID   string `json:"job_id"`
-	Message string `json:"message"`
-	Phase   Phase  `json:"phase"`
+	Sequence   uint64    `json:"sequence"`   // incrementing sequence number of the event for the deployment
+	Deployment string    `json:"deployment"` // deployment the event was emitted for
+	JobID      string    `json:"job_id"`
+	Type       EventType `json:"type"`
+	Message    string    `json:"message"`
+	Phase      Phase     `json:"phase"`
+	CreatedAt  string    `json:"created_at"` // RFC3339 date the event was emitted
 }
 
-var eventClients = make(map[string][]*websocket.Conn)
+// EventType is what an event reports on, the start, finish or error of a phase or a message emitted during a phase
+type EventType string
+
+const (
+	PhaseStartedEvent  EventType = "PHASE_STARTED"
+	PhaseFinishedEvent EventType = "PHASE_FINISHED"
+	PhaseErrorEvent    EventType = "PHASE_ERROR"
+	MessageEvent       EventType = "MESSAGE"
+)
 
 func createEventEmitter(deployment string, jobID string) *EventEmitter {
 	return &EventEmitter{
 		Deployment: deployment,
 		JobID:      jobID,
-		Clients:    eventClients[deployment],
 	}
 }
 
-// emit broadcasts an event payload to all clients connected to that deployment.
+// emit broadcasts a message for the current phase to all clients connected to that deployment
+func (e EventEmitter) emit(message string) { e.emitEvent(MessageEvent, message) }
+
+// emitEvent records an event and broadcasts it to all clients subscribed to that deployment.
 // In order to allow clients to filter events for specific deployment runs, the job id
 // was added into the event payload, the job id is returned when triggering a deployment run.
-func (e EventEmitter) emit(message string) {
-	go func(clients []*websocket.Conn, jobID string, deployment string, phase Phase) {
-		for _, client := range clients {
-			bytes, _ := json.Marshal(Event{
-				JobID:   jobID,
-				Message: message,
-				Phase:   phase,
-			})
-			if err := client.WriteMessage(websocket.TextMessage, bytes); err != nil {
-				// this will log when a client has disconnected at which point the
-				// connection is not valid causing a write error. This should not
-				// affect other clients or streaming logs in general.
-				logger.Debugf("client %v disconnected", client.RemoteAddr())
-				UnSubscribeFromDeploymentEvents(client, deployment)
-				return
-			}
-		}
-	}(e.Clients, e.JobID, e.Deployment, e.Phase)
+// Events are delivered in the order they are emitted so clients see a phase start before it finishes.
+func (e EventEmitter) emitEvent(eventType EventType, message string) {
+	e.publish(eventType, message, true)
+}
+
+func (e EventEmitter) publish(eventType EventType, message string, record bool) {
+	hub.publish(Event{
+		Deployment: e.Deployment,
+		JobID:      e.JobID,
+		Type:       eventType,
+		Message:    message,
+		Phase:      e.Phase,
+		CreatedAt:  utils.UTCDateString(),
+	}, record)
 }
 
 // emitStream broadcast a stream of data to all clients connected to the deployment.
-// A stream could be the data when pulling an image, reading container logs etc... where an io.Reader is returned
+// A stream could be the data when pulling an image, reading container logs etc... where an io.Reader is returned.
+// Repeated progress lines of an image pull are broadcast without being recorded, only the first line
+// of every status of a layer (ie. Downloading, Extracting, Pull complete) is recorded.
 func (e EventEmitter) emitStream(reader io.Reader) {
+	statuses := make(map[string]string)
 	buffReader := bufio.NewReader(reader)
 	for {
 		bytes, _, err := buffReader.ReadLine()
@@ -66,37 +79,89 @@ func (e EventEmitter) emitStream(reader io.Reader) {
 			return
 		}
 
-		data, _ := json.Marshal(Event{
-			JobID:   e.JobID,
-			Message: string(bytes),
-			Phase:   e.Phase,
-		})
-		for _, client := range e.Clients {
-			if err := client.WriteMessage(websocket.TextMessage, data); err != nil {
-				// this will log when a client has disconnected at which point the
-				// connection is not valid causing a write error. This should not
-				// affect other clients or streaming logs in general.
-				logger.Debugf("client %v disconnected", client.RemoteAddr())
-				UnSubscribeFromDeploymentEvents(client, e.Deployment)
-				return
-			}
-		}
+		line := string(bytes)
+		e.publish(MessageEvent, line, !isRepeatedPullProgress(line, statuses))
+	}
+}
+
+// pullProgress is a progress line of an image pull
+type pullProgress struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress string `json:"progress"`
+}
+
+// isRepeatedPullProgress returns true for a progress line of a layer with the same status as its previous line,
+// statuses holds the last status of every layer
+func isRepeatedPullProgress(line string, statuses map[string]string) bool {
+	var p pullProgress
+	if err := json.Unmarshal([]byte(line), &p); err != nil || p.ID == "" {
+		return false
 	}
+
+	repeated := p.Progress != "" && statuses[p.ID] == p.Status
+	statuses[p.ID] = p.Status
+	return repeated
+}
+
+// SubscribeToDeploymentEvents streams a particular deployments events to a client until the client disconnects.
+// Recorded events matching the replay (if any) are sent to the client before any new events.
+func SubscribeToDeploymentEvents(client StreamClient, deployment string, replay EventReplay) {
+	s := hub.subscribe(EventFilter{Deployments: []string{deployment}}, deployment, replay)
+	streamEvents(client, s)
 }
 
-// SubscribeToDeploymentEvents allows clients to subscribes to a particular deployments events
-func SubscribeToDeploymentEvents(client *websocket.Conn, deployment string) {
-	eventClients[deployment] = append(eventClients[deployment], client)
+// SubscribeToEvents streams the events of every deployment matching a filter to a client until the client disconnects
+func SubscribeToEvents(client StreamClient, filter EventFilter) {
+	s := hub.subscribe(filter, "", EventReplay{})
+	streamEvents(client, s)
 }
 
-// UnSubscribeFromDeploymentEvents unsubscribes a client from deployment events
-func UnSubscribeFromDeploymentEvents(client *websocket.Conn, deployment string) {
-	for i, c := range eventClients[deployment] {
-		if c == client {
-			if err := client.Close(); err != nil {
-				logger.Warnf("unable to properly close client connection %v", err)
+// streamEvents sends the events of a subscription to a client until the client disconnects or is
+// evicted for being too slow. Clients are pinged periodically to keep the connection alive.
+func streamEvents(client StreamClient, s *subscription) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		hub.unsubscribe(s)
+		if err := client.Close(); err != nil {
+			logger.Debugf("unable to properly close client connection %v", err)
+		}
+	}()
+
+	send := func(event Event) error {
+		bytes, _ := json.Marshal(event)
+		return client.Send(strconv.FormatUint(event.Sequence, 10), bytes)
+	}
+
+	for _, event := range s.backlog {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				// the subscription was evicted for being too slow
+				return
+			}
+
+			if err := send(event); err != nil {
+				// this will log when a client has disconnected at which point the
+				// connection is not valid causing a write error. This should not
+				// affect other clients or streaming events in general.
+				logger.Debugf("client disconnected, %v", err)
+				return
+			}
+		case <-client.Done():
+			logger.Debug("client disconnected")
+			return
+		case <-ticker.C:
+			if err := client.Ping(); err != nil {
+				return
 			}
-			eventClients[deployment] = append(eventClients[deployment][:i], eventClients[deployment][i+1:]...)
 		}
 	}
 }
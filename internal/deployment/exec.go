@@ -0,0 +1,240 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// max size of a message sent by an exec client
+const execReadLimit = 64 * 1024
+
+// channels prefixing the output sent to exec clients as binary messages
+const (
+	StdoutChannel byte = 1
+	StderrChannel byte = 2
+)
+
+// ErrExecNotPermitted is returned when executing a command in a container not managed by Krane
+var ErrExecNotPermitted = errors.New("exec is only permitted in containers managed by Krane")
+
+// ExecOptions are the options of a command executed in a container
+type ExecOptions struct {
+	Cmd  []string // command and its arguments (default /bin/sh)
+	Tty  bool     // allocate a TTY, the output is not split into stdout and stderr with a TTY
+	Env  []string // environment variables formatted as KEY=value
+	User string   // user running the command (default the container user)
+}
+
+// Exec is a command created in a container
+type Exec struct {
+	ID          string
+	ContainerID string
+	Tty         bool
+}
+
+// ExecMessage is a control message exchanged with exec clients as a JSON text message. Clients send stdin
+// (also accepted as raw binary messages) and resize messages, exit is sent to clients once the command exits
+// and error if the command could not be started.
+type ExecMessage struct {
+	Type     string `json:"type"` // stdin, resize, exit or error
+	Data     string `json:"data,omitempty"`
+	Width    uint   `json:"width,omitempty"`
+	Height   uint   `json:"height,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+}
+
+// CreateExec creates a command in a running container, only containers managed by Krane are permitted
+func CreateExec(containerID string, options ExecOptions) (Exec, error) {
+	ctx := context.Background()
+	container, err := docker.GetClient().GetOneContainer(ctx, containerID)
+	if err != nil {
+		return Exec{}, err
+	}
+
+	if container.Config == nil || !isKraneManagedContainer(container) {
+		return Exec{}, ErrExecNotPermitted
+	}
+
+	if container.State == nil || !container.State.Running {
+		return Exec{}, fmt.Errorf("container %s is not running", containerID)
+	}
+
+	cmd := options.Cmd
+	if len(cmd) == 0 {
+		cmd = []string{"/bin/sh"}
+	}
+
+	id, err := docker.GetClient().CreateExec(ctx, container.ID, cmd, options.Tty, options.Env, options.User)
+	if err != nil {
+		return Exec{}, err
+	}
+
+	logger.Infof("Created exec %s in container %s running %v", id, container.Config.Hostname, cmd)
+	return Exec{ID: id, ContainerID: container.ID, Tty: options.Tty}, nil
+}
+
+// AttachExec starts a command and streams its standard streams over a WebSocket connection until the command
+// exits or the client disconnects. Output is sent as binary messages prefixed by the stdout or stderr channel.
+func AttachExec(conn *websocket.Conn, exec Exec) {
+	ws := &execConn{conn: conn}
+	defer func() {
+		if err := ws.close(); err != nil {
+			logger.Debugf("error closing exec client connection, %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attached, err := docker.GetClient().AttachExec(ctx, exec.ID, exec.Tty)
+	if err != nil {
+		logger.Warnf("unable to start exec %s, %v", exec.ID, err)
+		_ = ws.writeControl(ExecMessage{Type: "error", Data: err.Error()})
+		return
+	}
+	defer attached.Close()
+
+	outputDone := make(chan error, 1)
+	go func() {
+		outputDone <- docker.DemuxExecOutput(attached.Reader, exec.Tty, ws.channel(StdoutChannel), ws.channel(StderrChannel))
+	}()
+
+	inputDone := make(chan error, 1)
+	go func() {
+		inputDone <- readExecInput(conn, attached.Conn, func(width, height uint) error {
+			return docker.GetClient().ResizeExec(ctx, exec.ID, width, height)
+		})
+	}()
+
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-outputDone:
+			if err != nil {
+				logger.Debugf("exec %s output ended, %v", exec.ID, err)
+			}
+
+			message := ExecMessage{Type: "exit"}
+			if exitCode, exited, err := docker.GetClient().GetExecExitCode(ctx, exec.ID); err == nil && exited {
+				message.ExitCode = &exitCode
+			}
+			_ = ws.writeControl(message)
+			return
+		case err := <-inputDone:
+			// the command keeps its stdin closed once the client disconnects, interactive shells exit on their own
+			logger.Debugf("exec client disconnected, %v", err)
+			return
+		case <-ticker.C:
+			if err := ws.write(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readExecInput forwards the stdin and resize messages of a client until the client disconnects
+func readExecInput(conn *websocket.Conn, stdin io.Writer, resize func(width, height uint) error) error {
+	conn.SetReadLimit(execReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(pongWait)) })
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		// any message from the client shows it's still connected
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if messageType == websocket.BinaryMessage {
+			if _, err := stdin.Write(data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var message ExecMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			logger.Debugf("invalid exec message, %v", err)
+			continue
+		}
+
+		switch message.Type {
+		case "stdin":
+			if _, err := stdin.Write([]byte(message.Data)); err != nil {
+				return err
+			}
+		case "resize":
+			if message.Width == 0 || message.Height == 0 {
+				continue
+			}
+
+			if err := resize(message.Width, message.Height); err != nil {
+				logger.Debugf("unable to resize exec, %v", err)
+			}
+		default:
+			logger.Debugf("unknown exec message type %s", message.Type)
+		}
+	}
+}
+
+// execConn serializes the writes to an exec client
+type execConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *execConn) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// writeControl sends a control message as JSON
+func (c *execConn) writeControl(message ExecMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.write(websocket.TextMessage, data)
+}
+
+// channel returns a writer sending output as binary messages prefixed by a channel
+func (c *execConn) channel(channel byte) io.Writer {
+	return execChannelWriter{conn: c, channel: channel}
+}
+
+func (c *execConn) close() error {
+	_ = c.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
+
+type execChannelWriter struct {
+	conn    *execConn
+	channel byte
+}
+
+func (w execChannelWriter) Write(p []byte) (int, error) {
+	data := make([]byte, 0, len(p)+1)
+	data = append(data, w.channel)
+	data = append(data, p...)
+	if err := w.conn.write(websocket.BinaryMessage, data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
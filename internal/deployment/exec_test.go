@@ -0,0 +1,84 @@
+package deployment
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type resizeCall struct {
+	width  uint
+	height uint
+}
+
+func TestReadExecInput(t *testing.T) {
+	var stdin bytes.Buffer
+	resizes := make([]resizeCall, 0)
+	done := make(chan error)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		assert.Nil(t, err)
+		defer conn.Close()
+
+		done <- readExecInput(conn, &stdin, func(width, height uint) error {
+			resizes = append(resizes, resizeCall{width, height})
+			return nil
+		})
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.WriteMessage(websocket.BinaryMessage, []byte("ls -la\n")))
+	assert.Nil(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","width":120,"height":40}`)))
+	assert.Nil(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","width":0,"height":40}`)))
+	assert.Nil(t, client.WriteMessage(websocket.TextMessage, []byte(`not json`)))
+	assert.Nil(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type":"stdin","data":"exit\n"}`)))
+	assert.Nil(t, client.Close())
+
+	assert.Error(t, <-done)
+	assert.Equal(t, "ls -la\nexit\n", stdin.String())
+	assert.Equal(t, []resizeCall{{120, 40}}, resizes)
+}
+
+func TestExecOutputIsPrefixedByChannel(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		assert.Nil(t, err)
+
+		ws := &execConn{conn: conn}
+		_, _ = ws.channel(StdoutChannel).Write([]byte("hello"))
+		_, _ = ws.channel(StderrChannel).Write([]byte("oops"))
+		exitCode := 1
+		_ = ws.writeControl(ExecMessage{Type: "exit", ExitCode: &exitCode})
+		_ = ws.close()
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	messageType, data, err := client.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+	assert.Equal(t, append([]byte{StdoutChannel}, "hello"...), data)
+
+	_, data, err = client.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, append([]byte{StderrChannel}, "oops"...), data)
+
+	messageType, data, err = client.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.TextMessage, messageType)
+	assert.JSONEq(t, `{"type":"exit","exit_code":1}`, string(data))
+}
@@ -0,0 +1,258 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// HealthCheckType is the kind of probe used to verify a container is healthy
+type HealthCheckType string
+
+const (
+	HTTPHealthCheck    HealthCheckType = "http"
+	TCPHealthCheck     HealthCheckType = "tcp"
+	CommandHealthCheck HealthCheckType = "command"
+)
+
+const (
+	defaultHealthCheckInterval = 10
+	defaultHealthCheckTimeout  = 5
+	defaultHealthCheckRetries  = 10
+	defaultHealthCheckStatus   = http.StatusOK
+	defaultHealthCheckPath     = "/"
+)
+
+// HealthCheck represents how containers of a deployment are verified to be healthy. When no type is
+// set containers are only checked to be in a running state. Durations are expressed in seconds.
+type HealthCheck struct {
+	Type           HealthCheckType `json:"type"`            // http, tcp or command
+	Path           string          `json:"path"`            // path requested for http checks (default /)
+	Port           string          `json:"port"`            // container port probed for http and tcp checks (default target port)
+	ExpectedStatus int             `json:"expected_status"` // status code expected for http checks (default 200)
+	Command        string          `json:"command"`         // command executed in the container for command checks, healthy when it exits with 0
+	Interval       int             `json:"interval"`        // seconds between checks (default 10)
+	Timeout        int             `json:"timeout"`         // seconds before a check is considered failed (default 5)
+	Retries        int             `json:"retries"`         // consecutive failures before a container is considered unhealthy (default 10)
+	StartPeriod    int             `json:"start_period"`    // seconds given to containers to start before checks begin (default 0)
+	Docker         bool            `json:"docker"`          // register http and tcp checks as the container Docker healthcheck (default false)
+}
+
+// applyDefaults applies default health check values, the default port is used for http and tcp checks without a port
+func (hc *HealthCheck) applyDefaults(defaultPort string) {
+	*hc = hc.withDefaults()
+
+	if hc.Port == "" && (hc.Type == HTTPHealthCheck || hc.Type == TCPHealthCheck) {
+		hc.Port = defaultPort
+	}
+}
+
+// withDefaults returns a copy of the health check with default values applied
+func (hc HealthCheck) withDefaults() HealthCheck {
+	if hc.Interval == 0 {
+		hc.Interval = defaultHealthCheckInterval
+	}
+
+	if hc.Timeout == 0 {
+		hc.Timeout = defaultHealthCheckTimeout
+	}
+
+	if hc.Retries == 0 {
+		hc.Retries = defaultHealthCheckRetries
+	}
+
+	if hc.Type == HTTPHealthCheck {
+		if hc.Path == "" {
+			hc.Path = defaultHealthCheckPath
+		}
+
+		if hc.ExpectedStatus == 0 {
+			hc.ExpectedStatus = defaultHealthCheckStatus
+		}
+	}
+
+	return hc
+}
+
+// isValid returns an error if a health check is not valid
+func (hc HealthCheck) isValid() error {
+	if hc.Interval < 0 || hc.Timeout < 0 || hc.Retries < 0 || hc.StartPeriod < 0 {
+		return errors.New("interval, timeout, retries and start_period cannot be negative")
+	}
+
+	switch hc.Type {
+	case "":
+		return nil
+	case HTTPHealthCheck:
+		if hc.Port == "" {
+			return errors.New("port required for http health check")
+		}
+
+		if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+			return fmt.Errorf("path %s must start with /", hc.Path)
+		}
+
+		if hc.ExpectedStatus != 0 && (hc.ExpectedStatus < 100 || hc.ExpectedStatus > 599) {
+			return fmt.Errorf("invalid expected status %d", hc.ExpectedStatus)
+		}
+
+		return nil
+	case TCPHealthCheck:
+		if hc.Port == "" {
+			return errors.New("port required for tcp health check")
+		}
+		return nil
+	case CommandHealthCheck:
+		if hc.Command == "" {
+			return errors.New("command required for command health check")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown health check type %s", hc.Type)
+	}
+}
+
+// DockerHealthConfig returns the Docker healthcheck for a health check, nil when no Docker healthcheck is registered.
+// Command checks are always registered, http and tcp checks only when opted in (docker) since they rely on curl
+// and nc being available in the container image, images without them would be reported unhealthy by Docker.
+func (hc HealthCheck) DockerHealthConfig() *container.HealthConfig {
+	if hc.Type != CommandHealthCheck && !hc.Docker {
+		return nil
+	}
+
+	var test []string
+	switch hc.Type {
+	case HTTPHealthCheck:
+		url := fmt.Sprintf("http://localhost:%s%s", hc.Port, hc.Path)
+		test = []string{"CMD-SHELL", fmt.Sprintf("curl -s -o /dev/null -w '%%{http_code}' %s | grep -qx %d", url, hc.ExpectedStatus)}
+	case TCPHealthCheck:
+		test = []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %s", hc.Port)}
+	case CommandHealthCheck:
+		test = []string{"CMD-SHELL", hc.Command}
+	default:
+		return nil
+	}
+
+	// the docker api version used has no start period, the checks failing during the
+	// start period are allowed by adding them to the retries
+	retries := hc.Retries
+	if hc.StartPeriod > 0 && hc.Interval > 0 {
+		retries += (hc.StartPeriod + hc.Interval - 1) / hc.Interval
+	}
+
+	return &container.HealthConfig{
+		Test:     test,
+		Interval: time.Duration(hc.Interval) * time.Second,
+		Timeout:  time.Duration(hc.Timeout) * time.Second,
+		Retries:  retries,
+	}
+}
+
+// RetriableContainersHealthCheck returns an error if a container is considered unhealthy. Every container is probed
+// until a check passes or the retries run out, waiting the health check interval between attempts.
+func RetriableContainersHealthCheck(ctx context.Context, containers []KraneContainer, healthCheck HealthCheck) error {
+	hc := healthCheck.withDefaults()
+
+	if len(containers) > 0 && hc.StartPeriod > 0 {
+		if err := sleep(ctx, time.Duration(hc.StartPeriod)*time.Second); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range containers {
+		for i := 0; i <= hc.Retries; i++ {
+			if i > 0 {
+				if err := sleep(ctx, time.Duration(hc.Interval)*time.Second); err != nil {
+					return err
+				}
+			}
+
+			err := c.probe(ctx, hc)
+			if err == nil {
+				// if reached here container passed the health check
+				break
+			}
+
+			logger.Debugf("container %s health check %d/%d failed, %v", c.Name, i+1, hc.Retries+1, err)
+			if i == hc.Retries {
+				return fmt.Errorf("container %s is not healthy %v", c.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// probe runs a single health check against a container, the container must be running to be considered healthy
+func (c KraneContainer) probe(ctx context.Context, hc HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(hc.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	if !resp.State.Running {
+		return fmt.Errorf("container %s is not in running state", c.ID)
+	}
+
+	var ip string
+	if network, ok := resp.NetworkSettings.Networks[docker.KraneNetworkName]; ok {
+		ip = network.IPAddress
+	}
+
+	switch hc.Type {
+	case HTTPHealthCheck:
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s%s", net.JoinHostPort(ip, hc.Port), hc.Path), nil)
+		if err != nil {
+			return err
+		}
+
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != hc.ExpectedStatus {
+			return fmt.Errorf("expected status %d but got %d", hc.ExpectedStatus, res.StatusCode)
+		}
+	case TCPHealthCheck:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, hc.Port))
+		if err != nil {
+			return err
+		}
+		_ = conn.Close()
+	case CommandHealthCheck:
+		exitCode, err := docker.GetClient().RunContainerCommand(ctx, c.ID, []string{"sh", "-c", hc.Command})
+		if err != nil {
+			return err
+		}
+
+		if exitCode != 0 {
+			return fmt.Errorf("command exited with code %d", exitCode)
+		}
+	}
+
+	return nil
+}
+
+// sleep pauses for a duration, returns early with an error if the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckDefaults(t *testing.T) {
+	hc := HealthCheck{Type: HTTPHealthCheck}
+	hc.applyDefaults("8080")
+	assert.Equal(t, "8080", hc.Port)
+	assert.Equal(t, "/", hc.Path)
+	assert.Equal(t, 200, hc.ExpectedStatus)
+	assert.Equal(t, 10, hc.Interval)
+	assert.Equal(t, 5, hc.Timeout)
+	assert.Equal(t, 10, hc.Retries)
+
+	hc = HealthCheck{Type: CommandHealthCheck, Command: "true"}
+	hc.applyDefaults("8080")
+	assert.Empty(t, hc.Port)
+	assert.Empty(t, hc.Path)
+}
+
+func TestConfigHealthCheckDefaultPort(t *testing.T) {
+	config := Config{Name: "krane-test", Image: "biensupernice/krane", Ports: map[string]string{"": "9000"}, HealthCheck: HealthCheck{Type: TCPHealthCheck}}
+	config.applyDefaults()
+	assert.Equal(t, "9000", config.HealthCheck.Port)
+
+	config = Config{Name: "krane-test", Image: "biensupernice/krane", TargetPort: "3000", HealthCheck: HealthCheck{Type: HTTPHealthCheck}}
+	config.applyDefaults()
+	assert.Equal(t, "3000", config.HealthCheck.Port)
+}
+
+func TestInvalidHealthCheck(t *testing.T) {
+	assert.Nil(t, HealthCheck{}.isValid())
+	assert.Nil(t, HealthCheck{Type: HTTPHealthCheck, Port: "8080", Path: "/healthz"}.isValid())
+	assert.Nil(t, HealthCheck{Type: CommandHealthCheck, Command: "pg_isready"}.isValid())
+	assert.Error(t, HealthCheck{Type: "grpc"}.isValid())
+	assert.Error(t, HealthCheck{Type: HTTPHealthCheck}.isValid())
+	assert.Error(t, HealthCheck{Type: HTTPHealthCheck, Port: "8080", Path: "healthz"}.isValid())
+	assert.Error(t, HealthCheck{Type: HTTPHealthCheck, Port: "8080", ExpectedStatus: 42}.isValid())
+	assert.Error(t, HealthCheck{Type: TCPHealthCheck}.isValid())
+	assert.Error(t, HealthCheck{Type: CommandHealthCheck}.isValid())
+	assert.Error(t, HealthCheck{Retries: -1}.isValid())
+}
+
+func TestDockerHealthConfig(t *testing.T) {
+	assert.Nil(t, HealthCheck{}.DockerHealthConfig())
+
+	hc := HealthCheck{Type: CommandHealthCheck, Command: "pg_isready", StartPeriod: 15}.withDefaults()
+	dockerHC := hc.DockerHealthConfig()
+	assert.Equal(t, []string{"CMD-SHELL", "pg_isready"}, dockerHC.Test)
+	assert.Equal(t, 10*time.Second, dockerHC.Interval)
+	assert.Equal(t, 5*time.Second, dockerHC.Timeout)
+	// failures during the start period are covered by extra retries
+	assert.Equal(t, 12, dockerHC.Retries)
+
+	// http and tcp checks are only registered with docker when opted in
+	hc = HealthCheck{Type: HTTPHealthCheck, Port: "8080"}.withDefaults()
+	assert.Nil(t, hc.DockerHealthConfig())
+
+	hc.Docker = true
+	assert.Contains(t, hc.DockerHealthConfig().Test[1], "curl")
+
+	hc = HealthCheck{Type: TCPHealthCheck, Port: "5432"}.withDefaults()
+	assert.Nil(t, hc.DockerHealthConfig())
+
+	hc.Docker = true
+	assert.Equal(t, []string{"CMD-SHELL", "nc -z localhost 5432"}, hc.DockerHealthConfig().Test)
+}
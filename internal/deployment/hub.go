@@ -0,0 +1,170 @@
+package deployment
+
+import (
+	"sync"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// subscriberQueueSize is the amount of events queued for a subscriber before it's considered too slow and evicted
+const subscriberQueueSize = 512
+
+// EventFilter selects the events delivered to a subscriber, an empty filter matches every event
+type EventFilter struct {
+	Deployments []string                     // only deliver events for these deployments
+	Phases      []Phase                      // only deliver events for these phases
+	InScope     func(deployment string) bool // only deliver events for deployments within a session scope (optional)
+}
+
+// matches returns true if an event should be delivered for the filter
+func (f EventFilter) matches(e Event) bool {
+	if f.InScope != nil && !f.InScope(e.Deployment) {
+		return false
+	}
+
+	if len(f.Deployments) > 0 {
+		found := false
+		for _, d := range f.Deployments {
+			if d == e.Deployment {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Phases) > 0 {
+		for _, p := range f.Phases {
+			if p == e.Phase {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// subscription is a subscriber of the event hub. Events are queued on a buffered channel which is closed
+// when the subscriber unsubscribes or is evicted for not keeping up with the events published.
+type subscription struct {
+	filter  EventFilter
+	events  chan Event
+	backlog []Event // recorded events replayed before any queued events
+}
+
+// eventHub broadcasts the events of every deployment to its subscribers. The hub lock is only held to queue events
+// for subscribers, events are recorded under a lock per deployment held until the event is queued so subscribers
+// receive the events of a deployment in the order they are emitted without publishers waiting on each other.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscription]bool
+}
+
+var hub = &eventHub{subscribers: make(map[*subscription]bool)}
+
+// eventLocks holds a mutex per deployment serializing the recording and delivery of its events
+var eventLocks sync.Map
+
+// lockEvents locks the events of a deployment, returns the fn unlocking them
+func lockEvents(deployment string) func() {
+	lock, _ := eventLocks.LoadOrStore(deployment, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
+
+// publish records an event (unless record is false) and queues it for every subscriber matching the event,
+// subscribers whose queue is full are evicted instead of blocking the publisher. Returns the published event.
+func (h *eventHub) publish(event Event, record bool) Event {
+	unlock := lockEvents(event.Deployment)
+	defer unlock()
+
+	if record {
+		var err error
+		event, err = recordEvent(event)
+		if err != nil {
+			logger.Warnf("unable to record event for deployment %s, %v", event.Deployment, err)
+		}
+	} else {
+		// events not recorded carry the sequence of the last recorded event so clients can still resume from it
+		event.Sequence, _ = loadEventSequence(event.Deployment)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subscribers {
+		if !s.filter.matches(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			logger.Debugf("Evicting event subscriber, %d events queued", len(s.events))
+			h.remove(s)
+		}
+	}
+
+	return event
+}
+
+// subscribe registers a subscriber for the events matching a filter. Recorded events of a deployment
+// matching the replay (if any) are set as the backlog of the subscription.
+func (h *eventHub) subscribe(filter EventFilter, deployment string, replay EventReplay) *subscription {
+	s := &subscription{
+		filter:  filter,
+		events:  make(chan Event, subscriberQueueSize),
+		backlog: make([]Event, 0),
+	}
+
+	if !replay.empty() {
+		// the deployment events stay locked until the subscriber is registered so
+		// events are either part of the backlog or queued, never both or neither
+		unlock := lockEvents(deployment)
+		defer unlock()
+
+		events, err := getEvents(deployment, replay)
+		if err != nil {
+			logger.Warnf("unable to replay events for deployment %s, %v", deployment, err)
+		}
+		s.backlog = events
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribers[s] = true
+	streamSubscribers.WithLabelValues("events").Inc()
+	return s
+}
+
+// unsubscribe removes a subscriber from the hub, it's safe to unsubscribe an evicted subscriber
+func (h *eventHub) unsubscribe(s *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(s)
+}
+
+// remove removes a subscriber and closes its queue, the hub lock must be held
+func (h *eventHub) remove(s *subscription) {
+	if !h.subscribers[s] {
+		return
+	}
+
+	delete(h.subscribers, s)
+	close(s.events)
+	streamSubscribers.WithLabelValues("events").Dec()
+}
+
+// count returns the amount of subscribers
+func (h *eventHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers)
+}
@@ -0,0 +1,93 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFilter(t *testing.T) {
+	event := Event{Deployment: "api", Phase: PullImagePhase}
+
+	assert.True(t, EventFilter{}.matches(event))
+	assert.True(t, EventFilter{Deployments: []string{"web", "api"}}.matches(event))
+	assert.False(t, EventFilter{Deployments: []string{"web"}}.matches(event))
+	assert.True(t, EventFilter{Phases: []Phase{HealthCheckPhase, PullImagePhase}}.matches(event))
+	assert.False(t, EventFilter{Phases: []Phase{HealthCheckPhase}}.matches(event))
+	assert.False(t, EventFilter{Deployments: []string{"api"}, Phases: []Phase{HealthCheckPhase}}.matches(event))
+	assert.True(t, EventFilter{InScope: func(d string) bool { return d == "api" }}.matches(event))
+	assert.False(t, EventFilter{InScope: func(d string) bool { return d == "web" }}.matches(event))
+}
+
+func TestHubPublish(t *testing.T) {
+	defer DeleteEventsCollection("krane-hub-api")
+	defer DeleteEventsCollection("krane-hub-web")
+
+	all := hub.subscribe(EventFilter{}, "", EventReplay{})
+	api := hub.subscribe(EventFilter{Deployments: []string{"krane-hub-api"}}, "", EventReplay{})
+	defer hub.unsubscribe(all)
+	defer hub.unsubscribe(api)
+
+	createEventEmitter("krane-hub-api", "job-1").emit("api event")
+	createEventEmitter("krane-hub-web", "job-2").emit("web event")
+
+	assert.Len(t, all.events, 2)
+	assert.Equal(t, "api event", (<-all.events).Message)
+	assert.Equal(t, "web event", (<-all.events).Message)
+
+	assert.Len(t, api.events, 1)
+	assert.Equal(t, "api event", (<-api.events).Message)
+}
+
+func TestHubEvictsSlowSubscribers(t *testing.T) {
+	defer DeleteEventsCollection("krane-hub-slow")
+
+	subscribed := func(s *subscription) bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return hub.subscribers[s]
+	}
+
+	slow := hub.subscribe(EventFilter{Deployments: []string{"krane-hub-slow"}}, "", EventReplay{})
+	assert.True(t, subscribed(slow))
+
+	e := createEventEmitter("krane-hub-slow", "job-1")
+	for i := 0; i <= subscriberQueueSize; i++ {
+		e.emit("event")
+	}
+
+	// the queue is closed once the subscriber is evicted
+	assert.False(t, subscribed(slow))
+	received := 0
+	for range slow.events {
+		received++
+	}
+	assert.Equal(t, subscriberQueueSize, received)
+
+	// unsubscribing an evicted subscriber is a no-op
+	hub.unsubscribe(slow)
+}
+
+func TestHubPublishIsNotBlockedByOtherDeployments(t *testing.T) {
+	defer DeleteEventsCollection("krane-hub-fast")
+
+	// a deployment recording an event holds its own lock, not the hub lock
+	unlock := lockEvents("krane-hub-busy")
+	defer unlock()
+
+	done := make(chan bool)
+	go func() {
+		s := hub.subscribe(EventFilter{Deployments: []string{"krane-hub-fast"}}, "", EventReplay{})
+		createEventEmitter("krane-hub-fast", "job-1").emit("event")
+		<-s.events
+		hub.unsubscribe(s)
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked behind another deployment")
+	}
+}
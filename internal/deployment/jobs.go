@@ -12,23 +12,54 @@ import (
 type JobType string
 
 const (
-	RunDeploymentJobType     JobType = "RUN_DEPLOYMENT"
-	DeleteDeploymentJobType  JobType = "DELETE_DEPLOYMENT"
-	StopContainersJobType    JobType = "STOP_CONTAINERS"
-	StartContainersJobType   JobType = "START_CONTAINERS"
-	RestartContainersJobType JobType = "RESTART_CONTAINERS"
+	RunDeploymentJobType       JobType = "RUN_DEPLOYMENT"
+	DeleteDeploymentJobType    JobType = "DELETE_DEPLOYMENT"
+	StopContainersJobType      JobType = "STOP_CONTAINERS"
+	StartContainersJobType     JobType = "START_CONTAINERS"
+	RestartContainersJobType   JobType = "RESTART_CONTAINERS"
+	ReconcileDeploymentJobType JobType = "RECONCILE_DEPLOYMENT"
 )
 
-// enqueue queues up deployment job for processing
-func enqueue(j job.Job) {
+// enqueue queues up deployment job for processing, the job is persisted before returning
+// but is handed off to the job queue without waiting for space to open up in the queue
+func enqueue(j job.Job) error {
 	enqueuer := job.NewEnqueuer(job.Queue())
-	queuedJob, err := enqueuer.Enqueue(j)
+	queuedJob, err := enqueuer.EnqueueAsync(j)
 	if err != nil {
 		logger.Errorf("Error enqueuing deployment job %v", err)
-		return
+		return err
 	}
 	logger.Debugf("Deployment job %s queued for processing", queuedJob.Deployment)
-	return
+	return nil
+}
+
+// RebuildJob re-creates a persisted deployment job so it can be resumed after a Krane restart.
+// Jobs based on a deployment configuration are rebuilt using the current configuration of the deployment.
+func RebuildJob(j job.Job) (job.Job, error) {
+	switch JobType(j.Type) {
+	case DeleteDeploymentJobType:
+		return deleteJob(j.Deployment, j.ID), nil
+	case StartContainersJobType:
+		return startContainersJob(j.Deployment, j.ID), nil
+	case StopContainersJobType:
+		return stopContainersJob(j.Deployment, j.ID), nil
+	}
+
+	config, err := GetDeploymentConfig(j.Deployment)
+	if err != nil {
+		return job.Job{}, err
+	}
+
+	switch JobType(j.Type) {
+	case RunDeploymentJobType:
+		return runJob(config, j.ID), nil
+	case RestartContainersJobType:
+		return restartContainersJob(config, j.ID), nil
+	case ReconcileDeploymentJobType:
+		return reconcileJob(config, j.ID), nil
+	default:
+		return job.Job{}, fmt.Errorf("unknown job type %s", j.Type)
+	}
 }
 
 // CreateCollection create the job collection for a deployment
@@ -80,14 +111,25 @@ func GetJobByID(deployment, id string, daysAgo uint) (job.Job, error) {
 	return job.Job{}, fmt.Errorf("unable to fnd job with id %s", id)
 }
 
+// CancelJob cancels a queued or running deployment job
+func CancelJob(deployment, id string) (job.Job, error) {
+	j, err := GetJobByID(deployment, id, 365)
+	if err != nil {
+		return job.Job{}, err
+	}
+
+	return job.Cancel(j)
+}
+
 // GetJobs returns all jobs for a deployment within a time range
 func GetJobsByDeployment(deployment string, daysAgo uint) ([]job.Job, error) {
 	// get start & end dates for the range of jobs to look for
 	minDate, maxDate := utils.CalculateTimeRange(int(daysAgo))
 
-	// get activity in time range
+	// get activity in time range, job keys are suffixed with the job id so the
+	// upper bound is extended to include jobs queued within the current second
 	collection := job.GetJobsCollectionName(deployment)
-	bytes, err := store.Client().GetInRange(collection, minDate, maxDate)
+	bytes, err := store.Client().GetInRange(collection, minDate, maxDate+"~")
 	if err != nil {
 		return make([]job.Job, 0), err
 	}
@@ -0,0 +1,150 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+// LogSinkType is the kind of external destination container logs are forwarded to
+type LogSinkType string
+
+const (
+	SyslogLogSink LogSinkType = "syslog"
+	HTTPLogSink   LogSinkType = "http"
+	FileLogSink   LogSinkType = "file"
+)
+
+const (
+	defaultLogSinkBufferSize  = 1000
+	defaultLogSinkMaxSizeMb   = 100
+	defaultLogSinkMaxFiles    = 5
+	defaultSyslogSinkProtocol = "udp"
+	defaultLogSinkDir         = "/var/log/krane"
+	// value of log sink headers returned by the api, headers usually hold credentials (ie. Authorization)
+	redactedHeaderValue = "<redacted>"
+)
+
+// LogSink represents an external destination the logs of every container of a deployment are forwarded to
+type LogSink struct {
+	Type       LogSinkType       `json:"type"`        // syslog, http or file
+	Protocol   string            `json:"protocol"`    // syslog transport, tcp or udp (default udp)
+	Address    string            `json:"address"`     // syslog server address (ie. logs.example.com:514)
+	URL        string            `json:"url"`         // http endpoint receiving batches of log messages as JSON
+	Headers    map[string]string `json:"headers"`     // http headers sent with every batch (ie. Authorization)
+	Path       string            `json:"path"`        // path of the log file relative to the deployment log sink directory
+	MaxSizeMb  int               `json:"max_size_mb"` // size at which the log file is rotated (default 100)
+	MaxFiles   int               `json:"max_files"`   // rotated log files kept (default 5)
+	BufferSize int               `json:"buffer_size"` // log messages buffered while the sink is unavailable (default 1000)
+}
+
+// applyDefaults applies default log sink values
+func (s *LogSink) applyDefaults() {
+	if s.BufferSize == 0 {
+		s.BufferSize = defaultLogSinkBufferSize
+	}
+
+	switch s.Type {
+	case SyslogLogSink:
+		if s.Protocol == "" {
+			s.Protocol = defaultSyslogSinkProtocol
+		}
+	case FileLogSink:
+		if s.MaxSizeMb == 0 {
+			s.MaxSizeMb = defaultLogSinkMaxSizeMb
+		}
+
+		if s.MaxFiles == 0 {
+			s.MaxFiles = defaultLogSinkMaxFiles
+		}
+	}
+}
+
+// isValid returns an error if a log sink is not valid
+func (s LogSink) isValid() error {
+	if s.BufferSize < 0 {
+		return errors.New("buffer_size cannot be negative")
+	}
+
+	switch s.Type {
+	case SyslogLogSink:
+		if s.Protocol != "tcp" && s.Protocol != "udp" {
+			return fmt.Errorf("unknown syslog protocol %s, expected tcp or udp", s.Protocol)
+		}
+
+		if _, _, err := net.SplitHostPort(s.Address); err != nil {
+			return fmt.Errorf("invalid syslog address %s, expected host:port", s.Address)
+		}
+
+		return nil
+	case HTTPLogSink:
+		u, err := url.Parse(s.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid http url %s", s.URL)
+		}
+
+		return nil
+	case FileLogSink:
+		if s.Path == "" {
+			return errors.New("path required for a file sink")
+		}
+
+		// file sinks are confined to the log sink directory of the deployment
+		path := filepath.Clean(s.Path)
+		if filepath.IsAbs(path) || path == "." || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file sink path %s, expected a relative path within the log sink directory", s.Path)
+		}
+
+		if s.MaxSizeMb < 0 || s.MaxFiles < 0 {
+			return errors.New("max_size_mb and max_files cannot be negative")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown log sink type %s", s.Type)
+	}
+}
+
+// FilePath returns the host path of the log file of a file sink, file sinks are written
+// within the directory of their deployment in the log sink directory (LOG_SINK_DIR)
+func (s LogSink) FilePath(deployment string) string {
+	dir := os.Getenv(constants.EnvLogSinkDir)
+	if dir == "" {
+		dir = defaultLogSinkDir
+	}
+	return filepath.Join(dir, deployment, filepath.Clean(s.Path))
+}
+
+// Redacted returns a copy of a log sink with the values of its headers masked
+func (s LogSink) Redacted() LogSink {
+	if len(s.Headers) == 0 {
+		return s
+	}
+
+	headers := make(map[string]string, len(s.Headers))
+	for key := range s.Headers {
+		headers[key] = redactedHeaderValue
+	}
+	s.Headers = headers
+	return s
+}
+
+// restoreRedactedHeaders sets the headers of a log sink left redacted (ie. a config read from the api
+// and saved again) back to the value of the same header of the previous log sink
+func (s *LogSink) restoreRedactedHeaders(previous LogSink) {
+	for key, value := range s.Headers {
+		if value != redactedHeaderValue {
+			continue
+		}
+
+		if previousValue, ok := previous.Headers[key]; ok {
+			s.Headers[key] = previousValue
+		}
+	}
+}
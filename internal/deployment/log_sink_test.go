@@ -0,0 +1,94 @@
+package deployment
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+func TestLogSinkDefaults(t *testing.T) {
+	syslog := LogSink{Type: SyslogLogSink, Address: "localhost:514"}
+	syslog.applyDefaults()
+	assert.Equal(t, "udp", syslog.Protocol)
+	assert.Equal(t, defaultLogSinkBufferSize, syslog.BufferSize)
+
+	file := LogSink{Type: FileLogSink, Path: "app.log"}
+	file.applyDefaults()
+	assert.Equal(t, defaultLogSinkMaxSizeMb, file.MaxSizeMb)
+	assert.Equal(t, defaultLogSinkMaxFiles, file.MaxFiles)
+}
+
+func TestLogSinkValidation(t *testing.T) {
+	assert.Nil(t, LogSink{Type: SyslogLogSink, Protocol: "tcp", Address: "logs.example.com:514"}.isValid())
+	assert.Nil(t, LogSink{Type: HTTPLogSink, URL: "https://logs.example.com/ingest"}.isValid())
+	assert.Nil(t, LogSink{Type: FileLogSink, Path: "app.log"}.isValid())
+
+	assert.Error(t, LogSink{Type: "kafka"}.isValid())
+	assert.Error(t, LogSink{Type: SyslogLogSink, Protocol: "quic", Address: "logs.example.com:514"}.isValid())
+	assert.Error(t, LogSink{Type: SyslogLogSink, Protocol: "udp", Address: "logs.example.com"}.isValid())
+	assert.Error(t, LogSink{Type: HTTPLogSink, URL: "logs.example.com/ingest"}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink, Path: "/var/lib/krane/krane.db"}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink, Path: "../other-deployment/app.log"}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink, Path: "logs/../../app.log"}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink, Path: "logs/.."}.isValid())
+	assert.Nil(t, LogSink{Type: FileLogSink, Path: "logs/../app.log"}.isValid())
+	assert.Error(t, LogSink{Type: FileLogSink, Path: "app.log", BufferSize: -1}.isValid())
+}
+
+func TestLogSinkFilePath(t *testing.T) {
+	defer os.Unsetenv(constants.EnvLogSinkDir)
+
+	sink := LogSink{Type: FileLogSink, Path: "logs/./app.log"}
+	assert.Equal(t, "/var/log/krane/api/logs/app.log", sink.FilePath("api"))
+
+	os.Setenv(constants.EnvLogSinkDir, "/data/logs")
+	assert.Equal(t, "/data/logs/api/logs/app.log", sink.FilePath("api"))
+}
+
+func TestInvalidLogSinkInDeploymentConfig(t *testing.T) {
+	config := Config{
+		Name:     "example-deployment",
+		Image:    "biensupernice/krane",
+		LogSinks: []LogSink{{Type: HTTPLogSink}},
+	}
+
+	err := config.isValid()
+	assert.EqualError(t, err, "invalid log sink in deployment config, invalid http url ")
+}
+
+func TestSavedLogSinkHeadersAreRedacted(t *testing.T) {
+	deployment := "krane-log-sink-headers-test"
+	defer DeleteConfig(deployment)
+	defer DeleteRevisionsCollection(deployment)
+
+	sink := LogSink{Type: HTTPLogSink, URL: "https://logs.example.com", Headers: map[string]string{"Authorization": "Bearer secret"}}
+	config := Config{Name: deployment, Image: "biensupernice/krane", LogSinks: []LogSink{sink}}
+	assert.Nil(t, SaveConfig(config, "root"))
+
+	redacted := config.Redacted()
+	assert.Equal(t, redactedHeaderValue, redacted.LogSinks[0].Headers["Authorization"])
+	assert.Equal(t, "Bearer secret", config.LogSinks[0].Headers["Authorization"])
+
+	// saving a redacted config keeps the saved header values
+	redacted.Scale = 2
+	assert.Nil(t, SaveConfig(redacted, "root"))
+	saved, err := GetDeploymentConfig(deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer secret", saved.LogSinks[0].Headers["Authorization"])
+
+	// changed headers are reported without their values
+	changed := saved
+	changed.LogSinks = []LogSink{{Type: HTTPLogSink, URL: "https://logs.example.com", Headers: map[string]string{"Authorization": "Bearer rotated"}}}
+	assert.Nil(t, SaveConfig(changed, "root"))
+
+	changes, err := DiffRevisions(deployment, 2, 3)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "log_sinks", changes[0].Field)
+	assert.NotContains(t, fmt.Sprint(changes[0].From, changes[0].To), "Bearer")
+}
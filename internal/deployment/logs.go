@@ -1,86 +1,109 @@
 package deployment
 
 import (
-	"github.com/gorilla/websocket"
+	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/krane/krane/internal/docker"
 	"github.com/krane/krane/internal/logger"
 )
 
-// SubscribeToDeploymentLogs streams deployment logs to a websocket client
-func SubscribeToDeploymentLogs(client *websocket.Conn, deployment string) {
-	data := make(chan []byte)
-	done := make(chan bool)
-
+// SubscribeToDeploymentLogs streams the logs of every container of a deployment to a client until the client
+// disconnects. Every line is sent as a log message identifying the container and stream it came from.
+func SubscribeToDeploymentLogs(client StreamClient, deployment string) {
 	containers, err := GetContainersByDeployment(deployment)
 	if err != nil {
 		logger.Warnf("unable to get containers for deployment %s, %v", deployment, err)
 		if err := client.Close(); err != nil {
 			logger.Warnf("error closing client connection, %v", err)
-			return
 		}
 		return
 	}
 
-	for _, container := range containers {
-		if err := docker.GetClient().StreamContainerLogs(container.ID, data, done); err != nil {
-			logger.Warnf("error grabbing container reader, %v", err)
-			if err := client.Close(); err != nil {
-				logger.Warnf("error closing client connection, %v", err)
-				return
-			}
-			return
-		}
-	}
+	streamLogs(client, containers)
+}
 
-	for {
-		select {
-		case bytes := <-data:
-			if err := client.WriteMessage(websocket.TextMessage, bytes); err != nil {
-				// this will log when a client has disconnected at which point the
-				// connection is not valid causing a write error. This should not
-				// affect other clients or streaming logs in general.
-				logger.Debugf("client %v disconnected", client.LocalAddr())
-				return
-			}
-		case <-done:
-			if err := client.Close(); err != nil {
-				logger.Warnf("error closing client connection when unsubscribing from container logs, %v", err)
-				return
-			}
+// SubscribeToContainerLogs streams container logs to a client until the client disconnects
+func SubscribeToContainerLogs(client StreamClient, containerID string) {
+	container, err := docker.GetClient().GetOneContainer(context.Background(), containerID)
+	if err != nil {
+		logger.Warnf("unable to get container %s, %v", containerID, err)
+		if err := client.Close(); err != nil {
+			logger.Warnf("error closing client connection, %v", err)
 		}
+		return
 	}
+
+	// the container is not required to be managed by Krane, only its id and name are needed
+	streamLogs(client, []KraneContainer{{ID: container.ID, Name: container.Config.Hostname}})
 }
 
-// SubscribeToContainerLogs streams container logs to a websocket client
-func SubscribeToContainerLogs(client *websocket.Conn, containerID string) {
-	data := make(chan []byte)
+// streamLogs sends the log lines of containers to a client until every log stream is done or the client disconnects
+func streamLogs(client StreamClient, containers []KraneContainer) {
+	streamSubscribers.WithLabelValues("logs").Inc()
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		streamSubscribers.WithLabelValues("logs").Dec()
+		// stop the container log streams still running
+		cancel()
+		ticker.Stop()
+		if err := client.Close(); err != nil {
+			logger.Debugf("error closing client connection when unsubscribing from logs, %v", err)
+		}
+	}()
+
+	data := make(chan docker.ContainerLogLine)
 	done := make(chan bool)
 
-	if err := docker.GetClient().StreamContainerLogs(containerID, data, done); err != nil {
-		logger.Warnf("error grabbing container reader, %v", err)
-		if err := client.Close(); err != nil {
-			logger.Warnf("error closing client connection, %v", err)
+	names := make(map[string]string)
+	for _, c := range containers {
+		names[c.ID] = c.Name
+		if err := docker.GetClient().StreamContainerLogs(ctx, c.ID, "", "200", data, done); err != nil {
+			logger.Warnf("error grabbing container reader, %v", err)
 			return
 		}
-		return
 	}
 
-	for {
+	streams := len(containers)
+	for streams > 0 {
 		select {
-		case bytes := <-data:
-			if err := client.WriteMessage(websocket.TextMessage, bytes); err != nil {
+		case l := <-data:
+			bytes, _ := json.Marshal(NewLogMessage(l, names[l.ContainerID]))
+			if err := client.Send("", bytes); err != nil {
 				// this will log when a client has disconnected at which point the
 				// connection is not valid causing a write error. This should not
 				// affect other clients or streaming logs in general.
-				logger.Debugf("client %v disconnected", client.LocalAddr())
+				logger.Debugf("client disconnected, %v", err)
 				return
 			}
 		case <-done:
-			if err := client.Close(); err != nil {
-				logger.Warnf("error closing client connection when unsubscribing from container logs, %v", err)
+			streams--
+		case <-client.Done():
+			logger.Debug("client disconnected")
+			return
+		case <-ticker.C:
+			if err := client.Ping(); err != nil {
 				return
 			}
 		}
 	}
 }
+
+// NewLogMessage converts a line of container logs into a log message, lines without a timestamp are kept as is
+func NewLogMessage(l docker.ContainerLogLine, containerName string) LogMessage {
+	message := LogMessage{
+		ContainerID:   l.ContainerID,
+		ContainerName: containerName,
+		Stream:        l.Stream,
+		Line:          l.Line,
+	}
+
+	if timestamp, line, ok := parseLogLine(l.Line); ok {
+		message.Timestamp = timestamp.Format(time.RFC3339Nano)
+		message.Line = line
+	}
+
+	return message
+}
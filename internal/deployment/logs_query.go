@@ -0,0 +1,163 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// LogMessage is a single line of logs from a container of a deployment
+type LogMessage struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Stream        string `json:"stream"`    // stdout or stderr
+	Timestamp     string `json:"timestamp"` // RFC3339Nano timestamp of the line set by Docker
+	Line          string `json:"line"`
+}
+
+// LogQuery selects the logs of a deployment, logs from every container are merged and ordered by timestamp
+type LogQuery struct {
+	Since  time.Time      // only logs after this time (inclusive), zero means no lower bound
+	Until  time.Time      // only logs before this time (inclusive), zero means no upper bound
+	Tail   int            // only the last n lines once filtered and merged, 0 means every line
+	Stdout bool           // include logs written to stdout
+	Stderr bool           // include logs written to stderr
+	Filter string         // only lines containing this substring
+	Regex  *regexp.Regexp // only lines matching this regular expression
+}
+
+// matches returns true if a log line is selected by the query
+func (q LogQuery) matches(timestamp time.Time, line string) bool {
+	if !q.Since.IsZero() && timestamp.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && timestamp.After(q.Until) {
+		return false
+	}
+
+	if q.Filter != "" && !strings.Contains(line, q.Filter) {
+		return false
+	}
+
+	if q.Regex != nil && !q.Regex.MatchString(line) {
+		return false
+	}
+
+	return true
+}
+
+// GetDeploymentLogs returns the logs of every container of a deployment matching a query ordered by timestamp
+func GetDeploymentLogs(deployment string, query LogQuery) ([]LogMessage, error) {
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return make([]LogMessage, 0), err
+	}
+
+	// the last lines of every container are enough to compute the last lines of the merged
+	// logs, unless lines are filtered in which case every line needs to be read
+	tail := "all"
+	if query.Tail > 0 && query.Until.IsZero() && query.Filter == "" && query.Regex == nil {
+		tail = strconv.Itoa(query.Tail)
+	}
+
+	since := ""
+	if !query.Since.IsZero() {
+		since = fmt.Sprintf("%d.%09d", query.Since.Unix(), query.Since.Nanosecond())
+	}
+
+	type timestampedMessage struct {
+		time    time.Time
+		message LogMessage
+	}
+
+	messages := make([]timestampedMessage, 0)
+	for _, c := range containers {
+		stdout, stderr, err := docker.GetClient().ReadContainerLogs(context.Background(), c.ID, query.Stdout, query.Stderr, since, tail)
+		if err != nil {
+			return make([]LogMessage, 0), fmt.Errorf("unable to read logs for container %s, %v", c.Name, err)
+		}
+
+		streams := []struct {
+			name string
+			logs []byte
+		}{{docker.StdoutStream, stdout}, {docker.StderrStream, stderr}}
+
+		for _, stream := range streams {
+			for _, line := range bytes.Split(stream.logs, []byte("\n")) {
+				timestamp, text, ok := parseLogLine(string(line))
+				if !ok || !query.matches(timestamp, text) {
+					continue
+				}
+
+				messages = append(messages, timestampedMessage{
+					time: timestamp,
+					message: LogMessage{
+						ContainerID:   c.ID,
+						ContainerName: c.Name,
+						Stream:        stream.name,
+						Timestamp:     timestamp.Format(time.RFC3339Nano),
+						Line:          text,
+					},
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].time.Before(messages[j].time) })
+
+	if query.Tail > 0 && len(messages) > query.Tail {
+		messages = messages[len(messages)-query.Tail:]
+	}
+
+	logs := make([]LogMessage, 0, len(messages))
+	for _, m := range messages {
+		logs = append(logs, m.message)
+	}
+
+	return logs, nil
+}
+
+// parseLogLine splits a log line into its Docker timestamp and text, returns false if the line has no timestamp
+func parseLogLine(line string) (time.Time, string, bool) {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", false
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	return timestamp, parts[1], true
+}
+
+// ParseLogTime parses a time used to query logs. Times can be RFC3339 dates, unix timestamps
+// or durations relative to now (ie. 10m for 10 minutes ago), an empty value returns the zero time.
+func ParseLogTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s, expected an RFC3339 date, unix timestamp or duration", value)
+}
@@ -0,0 +1,80 @@
+package deployment
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+func TestParseLogLine(t *testing.T) {
+	timestamp, line, ok := parseLogLine("2020-11-03T20:05:03.123456789Z GET /health 200")
+	assert.True(t, ok)
+	assert.Equal(t, "GET /health 200", line)
+	assert.Equal(t, 123456789, timestamp.Nanosecond())
+
+	_, _, ok = parseLogLine("")
+	assert.False(t, ok)
+
+	_, _, ok = parseLogLine("GET /health 200")
+	assert.False(t, ok)
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2020, 11, 3, 20, 0, 0, 0, time.UTC)
+
+	empty, err := ParseLogTime("", now)
+	assert.Nil(t, err)
+	assert.True(t, empty.IsZero())
+
+	date, err := ParseLogTime("2020-11-03T19:00:00Z", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), date.Unix())
+
+	unix, err := ParseLogTime("1604430000", now)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1604430000), unix.Unix())
+
+	relative, err := ParseLogTime("10m", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-10*time.Minute), relative)
+
+	_, err = ParseLogTime("yesterday", now)
+	assert.Error(t, err)
+
+	_, err = ParseLogTime("-10m", now)
+	assert.Error(t, err)
+}
+
+func TestLogQueryMatches(t *testing.T) {
+	timestamp := time.Date(2020, 11, 3, 20, 0, 0, 0, time.UTC)
+
+	assert.True(t, LogQuery{}.matches(timestamp, "GET /health 200"))
+	assert.True(t, LogQuery{Since: timestamp, Until: timestamp}.matches(timestamp, "GET /health 200"))
+	assert.False(t, LogQuery{Since: timestamp.Add(time.Second)}.matches(timestamp, "GET /health 200"))
+	assert.False(t, LogQuery{Until: timestamp.Add(-time.Second)}.matches(timestamp, "GET /health 200"))
+	assert.True(t, LogQuery{Filter: "/health"}.matches(timestamp, "GET /health 200"))
+	assert.False(t, LogQuery{Filter: "POST"}.matches(timestamp, "GET /health 200"))
+	assert.True(t, LogQuery{Regex: regexp.MustCompile(`\s2\d\d$`)}.matches(timestamp, "GET /health 200"))
+	assert.False(t, LogQuery{Regex: regexp.MustCompile(`\s5\d\d$`)}.matches(timestamp, "GET /health 200"))
+}
+
+func TestToLogMessage(t *testing.T) {
+	line := docker.ContainerLogLine{ContainerID: "b1946ac92492", Stream: docker.StderrStream, Line: "2020-11-03T20:05:03.5Z connection refused"}
+
+	message := NewLogMessage(line, "api-1")
+	assert.Equal(t, LogMessage{
+		ContainerID:   "b1946ac92492",
+		ContainerName: "api-1",
+		Stream:        docker.StderrStream,
+		Timestamp:     "2020-11-03T20:05:03.5Z",
+		Line:          "connection refused",
+	}, message)
+
+	line.Line = "connection refused"
+	assert.Equal(t, "connection refused", NewLogMessage(line, "api-1").Line)
+	assert.Empty(t, NewLogMessage(line, "api-1").Timestamp)
+}
@@ -0,0 +1,82 @@
+package deployment
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+var streamSubscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "krane",
+	Name:      "stream_subscribers",
+	Help:      "Clients subscribed to a realtime stream over WebSocket or Server-Sent Events, by stream (events, logs, stats).",
+}, []string{"stream"})
+
+func init() {
+	prometheus.MustRegister(containerCollector{
+		containers: prometheus.NewDesc(
+			"krane_deployment_containers",
+			"Containers of a deployment by status (created, running, exited...).",
+			[]string{"deployment", "status"}, nil),
+		health: prometheus.NewDesc(
+			"krane_deployment_containers_health",
+			"Containers of a deployment by health status (healthy, unhealthy, starting or none without a health check).",
+			[]string{"deployment", "health"}, nil),
+	})
+}
+
+// containerCollector collects the containers of every deployment when metrics are scraped
+type containerCollector struct {
+	containers *prometheus.Desc
+	health     *prometheus.Desc
+}
+
+func (c containerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.containers
+	ch <- c.health
+}
+
+func (c containerCollector) Collect(ch chan<- prometheus.Metric) {
+	containers, err := GetContainers()
+	if err != nil {
+		logger.Warnf("unable to collect container metrics, %v", err)
+		return
+	}
+
+	for key, count := range countContainers(containers) {
+		ch <- prometheus.MustNewConstMetric(c.containers, prometheus.GaugeValue, float64(count), key.deployment, key.status)
+	}
+
+	for key, count := range countContainerHealth(containers) {
+		ch <- prometheus.MustNewConstMetric(c.health, prometheus.GaugeValue, float64(count), key.deployment, key.status)
+	}
+}
+
+// containerCountKey identifies a container count by deployment and (health) status
+type containerCountKey struct {
+	deployment string
+	status     string
+}
+
+// countContainers counts the containers of every deployment by status
+func countContainers(containers []KraneContainer) map[containerCountKey]int {
+	counts := make(map[containerCountKey]int)
+	for _, c := range containers {
+		counts[containerCountKey{c.Deployment, c.State.Status}]++
+	}
+	return counts
+}
+
+// countContainerHealth counts the containers of every deployment by health status
+func countContainerHealth(containers []KraneContainer) map[containerCountKey]int {
+	counts := make(map[containerCountKey]int)
+	for _, c := range containers {
+		health := "none"
+		if c.State.Health != nil && c.State.Health.Status != "" {
+			health = c.State.Health.Status
+		}
+		counts[containerCountKey{c.Deployment, health}]++
+	}
+	return counts
+}
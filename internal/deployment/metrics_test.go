@@ -0,0 +1,29 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountContainers(t *testing.T) {
+	containers := []KraneContainer{
+		{Deployment: "api", State: ContainerState{Status: "running", Health: &types.Health{Status: "healthy"}}},
+		{Deployment: "api", State: ContainerState{Status: "running", Health: &types.Health{Status: "unhealthy"}}},
+		{Deployment: "api", State: ContainerState{Status: "exited", Health: &types.Health{Status: "unhealthy"}}},
+		{Deployment: "web", State: ContainerState{Status: "running"}},
+	}
+
+	assert.Equal(t, map[containerCountKey]int{
+		{"api", "running"}: 2,
+		{"api", "exited"}:  1,
+		{"web", "running"}: 1,
+	}, countContainers(containers))
+
+	assert.Equal(t, map[containerCountKey]int{
+		{"api", "healthy"}:   1,
+		{"api", "unhealthy"}: 2,
+		{"web", "none"}:      1,
+	}, countContainerHealth(containers))
+}
@@ -1,5 +1,12 @@
 package deployment
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/krane/krane/internal/job"
+)
+
 // Phases represents a particular step a deployment could be going through during its deployment cycle.
 // They are attached to jobs allowing clients to react or filter for particular phases of the deployments cycle.
 type Phase string
@@ -8,8 +15,36 @@ const (
 	SetupPhase           Phase = "DEPLOYMENT_SETUP"
 	HealthCheckPhase     Phase = "DEPLOYMENT_HEALTCHECK"
 	TeardownPhase        Phase = "DEPLOYMENT_TEARDOWN"
+	RollbackPhase        Phase = "DEPLOYMENT_ROLLBACK"
 	DonePhase            Phase = "DEPLOYMENT_DONE"
 	PullImagePhase       Phase = "PULL_IMAGE"
 	CreateContainerPhase Phase = "CREATE_CONTAINER"
 	StartContainerPhase  Phase = "START_CONTAINER"
+	StopContainerPhase   Phase = "STOP_CONTAINER"
+	RemoveContainerPhase Phase = "REMOVE_CONTAINER"
 )
+
+// runPhase executes a step of a deployment job as a phase. Events are emitted when the phase starts, finishes
+// or fails and the phase is recorded with its duration in the timeline of the job being executed.
+func runPhase(ctx context.Context, e *EventEmitter, phase Phase, fn func() error) error {
+	e.Phase = phase
+	e.emitEvent(PhaseStartedEvent, fmt.Sprintf("%s started", phase))
+
+	end := job.StartPhase(ctx, string(phase))
+	err := fn()
+	end(err)
+
+	if err != nil {
+		e.emitEvent(PhaseErrorEvent, err.Error())
+		return err
+	}
+
+	e.emitEvent(PhaseFinishedEvent, fmt.Sprintf("%s finished", phase))
+	return nil
+}
+
+// done emits the event marking the last step of a deployment job as complete
+func (e *EventEmitter) done() {
+	e.Phase = DonePhase
+	e.emitEvent(PhaseFinishedEvent, "Deployment job complete")
+}
@@ -0,0 +1,29 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPhase(t *testing.T) {
+	e := createEventEmitter("phases", "job-id")
+	defer DeleteEventsCollection("phases")
+
+	err := runPhase(context.Background(), e, PullImagePhase, func() error {
+		assert.Equal(t, PullImagePhase, e.Phase)
+		return nil
+	})
+	assert.Nil(t, err)
+
+	err = runPhase(context.Background(), e, HealthCheckPhase, func() error {
+		return errors.New("containers did not pass health check")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, HealthCheckPhase, e.Phase)
+
+	e.done()
+	assert.Equal(t, DonePhase, e.Phase)
+}
@@ -0,0 +1,135 @@
+package deployment
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/distribution/uuid"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// ReconcileJob returns a job which brings the containers of a deployment back in parity with its configuration.
+// Dead or exited containers are replaced, missing containers are re-created and containers beyond the configured
+// scale are removed. Unlike the other deployment jobs this job is not queued, it's up to the caller to enqueue it.
+func ReconcileJob(config Config) job.Job {
+	return reconcileJob(config, uuid.Generate().String())
+}
+
+// reconcileJob returns the reconcile job for a deployment configuration with a given job id
+func reconcileJob(config Config, jobID string) job.Job {
+	type ReconcileDeploymentJobArgs struct {
+		Config            Config
+		Containers        []KraneContainer
+		ContainersCreated []KraneContainer
+	}
+
+	e := createEventEmitter(config.Name, jobID)
+	return job.Job{
+		ID:          jobID,
+		Deployment:  config.Name,
+		Type:        string(ReconcileDeploymentJobType),
+		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
+		Args: &ReconcileDeploymentJobArgs{
+			Config:            config,
+			Containers:        []KraneContainer{},
+			ContainersCreated: []KraneContainer{},
+		},
+		Setup: func(ctx context.Context, args interface{}) error {
+			jobArgs := args.(*ReconcileDeploymentJobArgs)
+
+			return runPhase(ctx, e, SetupPhase, func() error {
+				// get the current containers for the deployment to compare against the desired state
+				containers, err := GetContainersByDeployment(jobArgs.Config.Name)
+				if err != nil {
+					logger.Errorf("unable to get containers %v", err)
+					return err
+				}
+
+				jobArgs.Containers = containers
+				return nil
+			})
+		},
+		Run: func(ctx context.Context, args interface{}) error {
+			jobArgs := args.(*ReconcileDeploymentJobArgs)
+			config := jobArgs.Config
+
+			// split containers into the ones still running and the ones that died or exited
+			running := make([]KraneContainer, 0)
+			stale := make([]KraneContainer, 0)
+			for _, c := range jobArgs.Containers {
+				if c.State.Running {
+					running = append(running, c)
+					continue
+				}
+				stale = append(stale, c)
+			}
+
+			// remove containers beyond the configured scale keeping the oldest ones around
+			if len(running) > config.Scale {
+				sort.Slice(running, func(i, j int) bool { return running[i].CreatedAt < running[j].CreatedAt })
+				logger.Debugf("Removing %d container(s) exceeding scale %d", len(running)-config.Scale, config.Scale)
+				stale = append(stale, running[config.Scale:]...)
+				running = running[:config.Scale]
+			}
+
+			// remove dead or exited containers, this is done before creating
+			// new containers to free up any host ports bound to them
+			if len(stale) > 0 {
+				if err := removeContainers(ctx, e, RemoveContainerPhase, stale); err != nil {
+					return err
+				}
+			}
+
+			missing := config.Scale - len(running)
+			if missing <= 0 {
+				logger.Debugf("Deployment %s reconciled, %d container(s) removed", config.Name, len(jobArgs.Containers)-len(running))
+				e.done()
+				return nil
+			}
+
+			// pull image in case it was removed from the host since the last run
+			if err := pullImage(ctx, e, config); err != nil {
+				return err
+			}
+
+			// re-create missing containers
+			containersCreated, err := createContainers(ctx, e, config, missing)
+			jobArgs.ContainersCreated = containersCreated
+			if err != nil {
+				return err
+			}
+
+			if err := startContainers(ctx, e, containersCreated); err != nil {
+				return err
+			}
+
+			if err := healthCheck(ctx, e, config, containersCreated); err != nil {
+				return err
+			}
+			logger.Debugf("Deployment %s reconciled", config.Name)
+
+			e.done()
+			return nil
+		},
+		Rollback: func(ctx context.Context, args interface{}) error {
+			jobArgs := args.(*ReconcileDeploymentJobArgs)
+
+			// tear down the containers created by the failed reconcile so the next
+			// attempt does not count unhealthy containers as part of the desired state
+			err := runPhase(ctx, e, RollbackPhase, func() error {
+				return rollback(ctx, e, jobArgs.ContainersCreated, nil)
+			})
+			if err != nil {
+				logger.Errorf("unable to rollback deployment %v", err)
+				return err
+			}
+
+			jobArgs.ContainersCreated = []KraneContainer{}
+			return nil
+		},
+	}
+}
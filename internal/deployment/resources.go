@@ -0,0 +1,165 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// Resources represents the resource limits applied to every container of a deployment.
+// Memory values are expressed as a size with an optional unit (ie. 512m, 1g), empty means no limit.
+type Resources struct {
+	Memory            string `json:"memory"`             // hard memory limit
+	MemoryReservation string `json:"memory_reservation"` // soft memory limit
+	CPUShares         int64  `json:"cpu_shares"`         // relative CPU weight vs. other containers
+	CPUQuota          int64  `json:"cpu_quota"`          // microseconds of CPU time per cpu period
+	CPUPeriod         int64  `json:"cpu_period"`         // length of a cpu period in microseconds (default 100000 when a quota is set)
+	PidsLimit         int64  `json:"pids_limit"`         // max number of processes in the container
+}
+
+// RestartPolicyName is the Docker restart policy applied to containers
+type RestartPolicyName string
+
+const (
+	RestartNo            RestartPolicyName = "no"
+	RestartAlways        RestartPolicyName = "always"
+	RestartUnlessStopped RestartPolicyName = "unless-stopped"
+	RestartOnFailure     RestartPolicyName = "on-failure"
+)
+
+// RestartPolicy represents how Docker restarts the containers of a deployment when they exit
+type RestartPolicy struct {
+	Name              RestartPolicyName `json:"name"`                // no (default), always, unless-stopped or on-failure
+	MaximumRetryCount int               `json:"maximum_retry_count"` // max restarts when using on-failure, 0 means unlimited
+}
+
+// isValid returns an error if resource limits are not valid
+func (r Resources) isValid() error {
+	memory, err := parseMemory(r.Memory)
+	if err != nil {
+		return fmt.Errorf("invalid memory %s", r.Memory)
+	}
+
+	memoryReservation, err := parseMemory(r.MemoryReservation)
+	if err != nil {
+		return fmt.Errorf("invalid memory_reservation %s", r.MemoryReservation)
+	}
+
+	if memory > 0 && memoryReservation > memory {
+		return errors.New("memory_reservation cannot be greater than memory")
+	}
+
+	if r.CPUShares < 0 || r.CPUQuota < 0 || r.CPUPeriod < 0 || r.PidsLimit < 0 {
+		return errors.New("cpu_shares, cpu_quota, cpu_period and pids_limit cannot be negative")
+	}
+
+	// docker only accepts a cpu quota and period of at least 1ms
+	if r.CPUQuota > 0 && r.CPUQuota < 1000 {
+		return errors.New("cpu_quota must be at least 1000")
+	}
+
+	if r.CPUPeriod > 0 && (r.CPUPeriod < 1000 || r.CPUPeriod > 1000000) {
+		return errors.New("cpu_period must be between 1000 and 1000000")
+	}
+
+	return nil
+}
+
+// DockerResources returns the Docker resources for resource limits, values are assumed to be valid
+func (r Resources) DockerResources() container.Resources {
+	memory, _ := parseMemory(r.Memory)
+	memoryReservation, _ := parseMemory(r.MemoryReservation)
+
+	return container.Resources{
+		Memory:            memory,
+		MemoryReservation: memoryReservation,
+		CPUShares:         r.CPUShares,
+		CPUQuota:          r.CPUQuota,
+		CPUPeriod:         r.CPUPeriod,
+		PidsLimit:         r.PidsLimit,
+	}
+}
+
+// isValid returns an error if a restart policy is not valid
+func (rp RestartPolicy) isValid() error {
+	if rp.MaximumRetryCount < 0 {
+		return errors.New("maximum_retry_count cannot be negative")
+	}
+
+	switch rp.Name {
+	case "", RestartNo, RestartAlways, RestartUnlessStopped:
+		if rp.MaximumRetryCount > 0 {
+			return fmt.Errorf("maximum_retry_count can only be used with the %s restart policy", RestartOnFailure)
+		}
+		return nil
+	case RestartOnFailure:
+		return nil
+	default:
+		return fmt.Errorf("unknown restart policy %s", rp.Name)
+	}
+}
+
+// DockerRestartPolicy returns the Docker restart policy for a restart policy
+func (rp RestartPolicy) DockerRestartPolicy() container.RestartPolicy {
+	return container.RestartPolicy{
+		Name:              string(rp.Name),
+		MaximumRetryCount: rp.MaximumRetryCount,
+	}
+}
+
+// signalNameRegex matches signal names with or without the SIG prefix (ie. SIGTERM, TERM, SIGRTMIN+3)
+var signalNameRegex = regexp.MustCompile(`^(SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?$`)
+
+// isValidStopSignal returns an error if a stop signal is not a signal name or number
+func isValidStopSignal(stopSignal string) error {
+	if stopSignal == "" {
+		return nil
+	}
+
+	if n, err := strconv.Atoi(stopSignal); err == nil {
+		if n <= 0 || n > 64 {
+			return fmt.Errorf("invalid signal number %d", n)
+		}
+		return nil
+	}
+
+	if !signalNameRegex.MatchString(stopSignal) {
+		return fmt.Errorf("invalid signal name %s", stopSignal)
+	}
+
+	return nil
+}
+
+// parseMemory returns the bytes for a memory size, 0 when no size is set
+func parseMemory(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+
+	return units.RAMInBytes(size)
+}
+
+// formatMemory returns a memory size for bytes, empty when no limit is set
+func formatMemory(bytes int64) string {
+	if bytes <= 0 {
+		return ""
+	}
+
+	return units.BytesSize(float64(bytes))
+}
+
+// fromDockerResourcesToResources converts docker container resources into Krane resources
+func fromDockerResourcesToResources(resources container.Resources) Resources {
+	return Resources{
+		Memory:            formatMemory(resources.Memory),
+		MemoryReservation: formatMemory(resources.MemoryReservation),
+		CPUShares:         resources.CPUShares,
+		CPUQuota:          resources.CPUQuota,
+		CPUPeriod:         resources.CPUPeriod,
+		PidsLimit:         resources.PidsLimit,
+	}
+}
@@ -0,0 +1,56 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidResources(t *testing.T) {
+	assert.Nil(t, Resources{}.isValid())
+	assert.Nil(t, Resources{Memory: "512m", MemoryReservation: "256m", CPUShares: 512, CPUQuota: 50000, CPUPeriod: 100000, PidsLimit: 100}.isValid())
+	assert.Error(t, Resources{Memory: "lots"}.isValid())
+	assert.Error(t, Resources{MemoryReservation: "-1m"}.isValid())
+	assert.Error(t, Resources{Memory: "256m", MemoryReservation: "512m"}.isValid())
+	assert.Error(t, Resources{CPUShares: -1}.isValid())
+	assert.Error(t, Resources{CPUQuota: 10}.isValid())
+	assert.Error(t, Resources{CPUPeriod: 2000000}.isValid())
+}
+
+func TestDockerResources(t *testing.T) {
+	resources := Resources{Memory: "512m", MemoryReservation: "1g", CPUShares: 512, CPUQuota: 50000, PidsLimit: 100}
+	assert.Equal(t, container.Resources{
+		Memory:            512 * 1024 * 1024,
+		MemoryReservation: 1024 * 1024 * 1024,
+		CPUShares:         512,
+		CPUQuota:          50000,
+		PidsLimit:         100,
+	}, resources.DockerResources())
+
+	reported := fromDockerResourcesToResources(resources.DockerResources())
+	assert.Equal(t, "512MiB", reported.Memory)
+	assert.Equal(t, "1GiB", reported.MemoryReservation)
+	assert.Equal(t, resources.DockerResources(), reported.DockerResources())
+	assert.Empty(t, fromDockerResourcesToResources(container.Resources{}).Memory)
+}
+
+func TestInvalidRestartPolicy(t *testing.T) {
+	assert.Nil(t, RestartPolicy{}.isValid())
+	assert.Nil(t, RestartPolicy{Name: RestartUnlessStopped}.isValid())
+	assert.Nil(t, RestartPolicy{Name: RestartOnFailure, MaximumRetryCount: 5}.isValid())
+	assert.Error(t, RestartPolicy{Name: "sometimes"}.isValid())
+	assert.Error(t, RestartPolicy{Name: RestartAlways, MaximumRetryCount: 5}.isValid())
+	assert.Error(t, RestartPolicy{Name: RestartOnFailure, MaximumRetryCount: -1}.isValid())
+}
+
+func TestStopSettingsInDeploymentConfig(t *testing.T) {
+	timeout := 30
+	assert.Nil(t, Config{Name: "example-deployment", Image: "biensupernice/krane", StopTimeout: &timeout, StopSignal: "SIGINT"}.isValid())
+	assert.Nil(t, Config{Name: "example-deployment", Image: "biensupernice/krane", StopSignal: "15"}.isValid())
+	assert.Error(t, Config{Name: "example-deployment", Image: "biensupernice/krane", StopSignal: "sigterm!"}.isValid())
+	assert.Error(t, Config{Name: "example-deployment", Image: "biensupernice/krane", StopSignal: "99"}.isValid())
+
+	timeout = -1
+	assert.Error(t, Config{Name: "example-deployment", Image: "biensupernice/krane", StopTimeout: &timeout}.isValid())
+}
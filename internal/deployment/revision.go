@@ -0,0 +1,247 @@
+package deployment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+	"github.com/krane/krane/internal/utils"
+)
+
+// Revision represents an immutable snapshot of a deployment configuration. A revision
+// is created every time a deployment configuration is saved with changes.
+type Revision struct {
+	Revision  int    `json:"revision"`   // incrementing revision number starting at 1
+	CreatedAt string `json:"created_at"` // RFC3339 date the revision was created
+	User      string `json:"user"`       // user of the session which saved the configuration
+	Config    Config `json:"config"`     // deployment configuration at the time of the revision
+}
+
+// Change represents a single difference between the configurations of two revisions
+type Change struct {
+	Field string      `json:"field"` // dot separated path to the field ie. env.NODE_ENV
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// revisionLocks holds a mutex per deployment so concurrent saves cannot allocate the same revision number
+var revisionLocks sync.Map
+
+// saveRevision stores a deployment configuration as the next revision for the deployment,
+// the latest revision is returned instead when its configuration is identical
+func saveRevision(config Config, user string) (Revision, error) {
+	lock, _ := revisionLocks.LoadOrStore(getRevisionsCollectionName(config.Name), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	revisions, err := GetRevisions(config.Name)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	next := 1
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		if sameConfig(latest.Config, config) {
+			return latest, nil
+		}
+		next = latest.Revision + 1
+	}
+
+	revision := Revision{
+		Revision:  next,
+		CreatedAt: utils.UTCDateString(),
+		User:      user,
+		Config:    config,
+	}
+
+	bytes, err := store.Serialize(revision)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	collection := getRevisionsCollectionName(config.Name)
+	if err := store.Client().Put(collection, formatRevisionKey(next), bytes); err != nil {
+		return Revision{}, err
+	}
+
+	return revision, nil
+}
+
+// sameConfig returns whether two deployment configurations are identical once serialized
+func sameConfig(a Config, b Config) bool {
+	aBytes, err := a.Serialize()
+	if err != nil {
+		return false
+	}
+
+	bBytes, err := b.Serialize()
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(aBytes, bBytes)
+}
+
+// GetRevisions returns all revisions for a deployment in ascending order
+func GetRevisions(deployment string) ([]Revision, error) {
+	collection := getRevisionsCollectionName(deployment)
+	bytes, err := store.Client().GetAll(collection)
+	if err != nil {
+		return make([]Revision, 0), err
+	}
+
+	revisions := make([]Revision, 0)
+	for _, b := range bytes {
+		var r Revision
+		if err := store.Deserialize(b, &r); err != nil {
+			return make([]Revision, 0), err
+		}
+		revisions = append(revisions, r)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a single revision for a deployment
+func GetRevision(deployment string, revision int) (Revision, error) {
+	collection := getRevisionsCollectionName(deployment)
+	bytes, err := store.Client().Get(collection, formatRevisionKey(revision))
+	if err != nil {
+		return Revision{}, err
+	}
+
+	if bytes == nil {
+		return Revision{}, fmt.Errorf("revision %d not found for deployment %s", revision, deployment)
+	}
+
+	var r Revision
+	if err := store.Deserialize(bytes, &r); err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
+
+// DiffRevisions returns the changes made to a deployment configuration between two revisions
+func DiffRevisions(deployment string, from, to int) ([]Change, error) {
+	fromRevision, err := GetRevision(deployment, from)
+	if err != nil {
+		return make([]Change, 0), err
+	}
+
+	toRevision, err := GetRevision(deployment, to)
+	if err != nil {
+		return make([]Change, 0), err
+	}
+
+	// changes are found on the saved configs so changed log sink headers are reported, but with redacted values
+	changes := diffConfigs(fromRevision.Config, toRevision.Config)
+	fromFields := flattenConfig(fromRevision.Config.Redacted())
+	toFields := flattenConfig(toRevision.Config.Redacted())
+	for i := range changes {
+		changes[i].From = fromFields[changes[i].Field]
+		changes[i].To = toFields[changes[i].Field]
+	}
+
+	return changes, nil
+}
+
+// Redacted returns a copy of a revision with its configuration redacted
+func (r Revision) Redacted() Revision {
+	r.Config = r.Config.Redacted()
+	return r
+}
+
+// RollbackToRevision restores the configuration of a previous revision and runs the deployment.
+// Restoring a revision saves its configuration as a new revision unless it is the latest revision,
+// the revision history is never rewritten.
+func RollbackToRevision(deployment string, revision int, user string) error {
+	r, err := GetRevision(deployment, revision)
+	if err != nil {
+		return err
+	}
+
+	if err := SaveConfig(r.Config, user); err != nil {
+		return err
+	}
+
+	return Run(deployment)
+}
+
+// DeleteRevisionsCollection deletes the revisions collection for a deployment
+func DeleteRevisionsCollection(deployment string) error {
+	collection := getRevisionsCollectionName(deployment)
+	return store.Client().DeleteCollection(collection)
+}
+
+// diffConfigs returns the fields which changed between two deployment configurations sorted by field name
+func diffConfigs(from, to Config) []Change {
+	fromFields := flattenConfig(from)
+	toFields := flattenConfig(to)
+
+	fields := make([]string, 0)
+	for field := range fromFields {
+		fields = append(fields, field)
+	}
+	for field := range toFields {
+		if _, ok := fromFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]Change, 0)
+	for _, field := range fields {
+		fromValue, toValue := fromFields[field], toFields[field]
+		if reflect.DeepEqual(fromValue, toValue) {
+			continue
+		}
+		changes = append(changes, Change{Field: field, From: fromValue, To: toValue})
+	}
+
+	return changes
+}
+
+// flattenConfig returns the json fields of a config keyed by their dot separated path
+func flattenConfig(config Config) map[string]interface{} {
+	bytes, _ := config.Serialize()
+
+	var fields map[string]interface{}
+	_ = json.Unmarshal(bytes, &fields)
+
+	flattened := make(map[string]interface{})
+	flatten("", fields, flattened)
+	return flattened
+}
+
+func flatten(prefix string, fields map[string]interface{}, out map[string]interface{}) {
+	for key, value := range fields {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(path, nested, out)
+			continue
+		}
+
+		out[path] = value
+	}
+}
+
+func formatRevisionKey(revision int) string {
+	// zero padded so revisions are sorted by their number in the store
+	return fmt.Sprintf("%010d", revision)
+}
+
+func getRevisionsCollectionName(deployment string) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s", deployment, constants.RevisionsCollectionName))
+}
@@ -0,0 +1,132 @@
+package deployment
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveConfigCreatesRevisions(t *testing.T) {
+	deployment := "krane-revisions-test"
+
+	assert.Nil(t, SaveConfig(Config{Name: deployment, Image: "biensupernice/krane", Scale: 1}, "root"))
+	assert.Nil(t, SaveConfig(Config{Name: deployment, Image: "biensupernice/krane", Scale: 3}, "ci"))
+
+	revisions, err := GetRevisions(deployment)
+	assert.Nil(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, "root", revisions[0].User)
+	assert.Equal(t, 1, revisions[0].Config.Scale)
+	assert.Equal(t, 2, revisions[1].Revision)
+	assert.Equal(t, "ci", revisions[1].User)
+	assert.Equal(t, 3, revisions[1].Config.Scale)
+
+	revision, err := GetRevision(deployment, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, revisions[1], revision)
+
+	_, err = GetRevision(deployment, 3)
+	assert.Error(t, err)
+
+	changes, err := DiffRevisions(deployment, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{{Field: "scale", From: float64(1), To: float64(3)}}, changes)
+
+	assert.Nil(t, DeleteRevisionsCollection(deployment))
+}
+
+func TestSavingAnUnchangedConfigDoesNotCreateRevision(t *testing.T) {
+	deployment := "krane-unchanged-revision-test"
+	config := Config{Name: deployment, Image: "biensupernice/krane", Scale: 1}
+
+	assert.Nil(t, SaveConfig(config, "root"))
+	assert.Nil(t, SaveConfig(config, "ci"))
+
+	revisions, err := GetRevisions(deployment)
+	assert.Nil(t, err)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "root", revisions[0].User)
+
+	// restoring an older revision creates a new revision
+	config.Scale = 2
+	assert.Nil(t, SaveConfig(config, "ci"))
+	first, err := GetRevision(deployment, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, SaveConfig(first.Config, "root"))
+
+	revisions, err = GetRevisions(deployment)
+	assert.Nil(t, err)
+	assert.Len(t, revisions, 3)
+	assert.Equal(t, 1, revisions[2].Config.Scale)
+
+	assert.Nil(t, DeleteRevisionsCollection(deployment))
+}
+
+func TestConcurrentSavesCreateDistinctRevisions(t *testing.T) {
+	deployment := "krane-concurrent-revisions-test"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(scale int) {
+			defer wg.Done()
+			_, err := saveRevision(Config{Name: deployment, Image: "biensupernice/krane", Scale: scale}, "ci")
+			assert.Nil(t, err)
+		}(i + 1)
+	}
+	wg.Wait()
+
+	revisions, err := GetRevisions(deployment)
+	assert.Nil(t, err)
+	assert.Len(t, revisions, 10)
+
+	scales := make([]int, 0)
+	for i, r := range revisions {
+		assert.Equal(t, i+1, r.Revision)
+		scales = append(scales, r.Config.Scale)
+	}
+	sort.Ints(scales)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, scales)
+
+	assert.Nil(t, DeleteRevisionsCollection(deployment))
+}
+
+func TestInvalidConfigDoesNotCreateRevision(t *testing.T) {
+	deployment := "krane-invalid-revision-test"
+
+	assert.Error(t, SaveConfig(Config{Name: deployment}, "root"))
+
+	revisions, err := GetRevisions(deployment)
+	assert.Nil(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestDiffConfigs(t *testing.T) {
+	from := Config{
+		Name:  "krane-test",
+		Image: "biensupernice/krane",
+		Tag:   "1.0.0",
+		Env:   map[string]string{"NODE_ENV": "dev", "PORT": "8080"},
+		Alias: []string{"krane.example.com"},
+	}
+
+	to := Config{
+		Name:  "krane-test",
+		Image: "biensupernice/krane",
+		Tag:   "1.1.0",
+		Env:   map[string]string{"NODE_ENV": "prod", "DEBUG": "true"},
+		Alias: []string{"krane.example.com"},
+	}
+
+	assert.Equal(t, []Change{
+		{Field: "env.DEBUG", From: nil, To: "true"},
+		{Field: "env.NODE_ENV", From: "dev", To: "prod"},
+		{Field: "env.PORT", From: "8080", To: nil},
+		{Field: "tag", From: "1.0.0", To: "1.1.0"},
+	}, diffConfigs(from, to))
+
+	assert.Empty(t, diffConfigs(from, from))
+}
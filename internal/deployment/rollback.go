@@ -0,0 +1,43 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// rollback tears down the containers created during a failed deployment run and ensures the containers
+// which were running before the run are still serving. Previous containers could have been retired
+// (stopped) by a rolling update, those are started back up.
+func rollback(ctx context.Context, e *EventEmitter, created []KraneContainer, previous []KraneContainer) error {
+	e.emit(fmt.Sprintf("Deployment failed, rolling back %d container(s)", len(created)))
+
+	for _, c := range created {
+		logger.Debugf("Rolling back container %s", c.Name)
+		if err := c.Remove(ctx); err != nil {
+			logger.Errorf("unable to remove container %v", err)
+			return err
+		}
+	}
+
+	for _, c := range previous {
+		// only bring back containers that were running before the deployment run
+		if !c.State.Running {
+			continue
+		}
+
+		if running, _ := c.Running(); running {
+			continue
+		}
+
+		logger.Debugf("Restoring container %s", c.Name)
+		if err := c.Start(ctx); err != nil {
+			logger.Errorf("unable to start container %v", err)
+			return err
+		}
+	}
+
+	e.emit("Deployment rolled back")
+	return nil
+}
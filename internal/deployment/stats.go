@@ -0,0 +1,188 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// ContainerStats is a sample of the resource usage of a container of a deployment
+type ContainerStats struct {
+	ContainerID     string  `json:"container_id"`
+	ContainerName   string  `json:"container_name"`
+	Timestamp       string  `json:"timestamp"`        // RFC3339Nano time the sample was read
+	CPUPercent      float64 `json:"cpu_percent"`      // percentage of the host cpus, can exceed 100 on multi-core hosts
+	MemoryUsage     uint64  `json:"memory_usage"`     // bytes used, excluding the page cache
+	MemoryLimit     uint64  `json:"memory_limit"`     // bytes available to the container
+	MemoryPercent   float64 `json:"memory_percent"`   // memory usage as a percentage of the limit
+	NetworkRxBytes  uint64  `json:"network_rx_bytes"` // bytes received across every network
+	NetworkTxBytes  uint64  `json:"network_tx_bytes"` // bytes sent across every network
+	BlockReadBytes  uint64  `json:"block_read_bytes"`
+	BlockWriteBytes uint64  `json:"block_write_bytes"`
+	Pids            uint64  `json:"pids"`
+}
+
+// NewContainerStats converts a Docker stats sample into container stats, usage is computed the same way as docker stats
+func NewContainerStats(containerName string, s types.StatsJSON) ContainerStats {
+	stats := ContainerStats{
+		ContainerID:   s.ID,
+		ContainerName: containerName,
+		Timestamp:     s.Read.UTC().Format(time.RFC3339Nano),
+		CPUPercent:    cpuPercent(s.CPUStats, s.PreCPUStats),
+		MemoryUsage:   s.MemoryStats.Usage,
+		MemoryLimit:   s.MemoryStats.Limit,
+		Pids:          s.PidsStats.Current,
+	}
+
+	// the page cache can be reclaimed and is not counted as used memory
+	if cache, ok := s.MemoryStats.Stats["cache"]; ok && cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	}
+
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+
+	for _, network := range s.Networks {
+		stats.NetworkRxBytes += network.RxBytes
+		stats.NetworkTxBytes += network.TxBytes
+	}
+
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockReadBytes += entry.Value
+		case "write":
+			stats.BlockWriteBytes += entry.Value
+		}
+	}
+
+	return stats
+}
+
+// cpuPercent returns the cpu usage between two samples as a percentage of the host cpus
+func cpuPercent(current types.CPUStats, previous types.CPUStats) float64 {
+	if current.CPUUsage.TotalUsage < previous.CPUUsage.TotalUsage || current.SystemUsage <= previous.SystemUsage {
+		return 0
+	}
+
+	cpuDelta := float64(current.CPUUsage.TotalUsage - previous.CPUUsage.TotalUsage)
+	systemDelta := float64(current.SystemUsage - previous.SystemUsage)
+
+	cpus := len(current.CPUUsage.PercpuUsage)
+	if cpus == 0 {
+		cpus = 1
+	}
+
+	return cpuDelta / systemDelta * float64(cpus) * 100
+}
+
+// GetDeploymentStats returns a sample of the resource usage of every running container of a deployment
+func GetDeploymentStats(deployment string) ([]ContainerStats, error) {
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return make([]ContainerStats, 0), err
+	}
+
+	running := make([]KraneContainer, 0)
+	for _, c := range containers {
+		if c.State.Running {
+			running = append(running, c)
+		}
+	}
+
+	// reading a sample takes about a second, containers are sampled concurrently
+	stats := make([]ContainerStats, len(running))
+	errs := make([]error, len(running))
+	var wg sync.WaitGroup
+	for i, c := range running {
+		wg.Add(1)
+		go func(i int, c KraneContainer) {
+			defer wg.Done()
+			sample, err := docker.GetClient().ReadContainerStats(context.Background(), c.ID)
+			if err != nil {
+				errs[i] = fmt.Errorf("unable to read stats for container %s, %v", c.Name, err)
+				return
+			}
+			stats[i] = NewContainerStats(c.Name, sample)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return make([]ContainerStats, 0), err
+		}
+	}
+
+	return stats, nil
+}
+
+// SubscribeToDeploymentStats streams the resource usage of every running container of a deployment to a client
+// until the client disconnects. Every container sends a sample about once per second.
+func SubscribeToDeploymentStats(client StreamClient, deployment string) {
+	streamSubscribers.WithLabelValues("stats").Inc()
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		streamSubscribers.WithLabelValues("stats").Dec()
+		// stop the container stats streams still running
+		cancel()
+		ticker.Stop()
+		if err := client.Close(); err != nil {
+			logger.Debugf("error closing client connection when unsubscribing from stats, %v", err)
+		}
+	}()
+
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		logger.Warnf("unable to get containers for deployment %s, %v", deployment, err)
+		return
+	}
+
+	data := make(chan types.StatsJSON)
+	done := make(chan bool)
+
+	names := make(map[string]string)
+	streams := 0
+	for _, c := range containers {
+		if !c.State.Running {
+			continue
+		}
+
+		names[c.ID] = c.Name
+		if err := docker.GetClient().StreamContainerStats(ctx, c.ID, data, done); err != nil {
+			logger.Warnf("error streaming stats for container %s, %v", c.Name, err)
+			return
+		}
+		streams++
+	}
+
+	for streams > 0 {
+		select {
+		case s := <-data:
+			bytes, _ := json.Marshal(NewContainerStats(names[s.ID], s))
+			if err := client.Send("", bytes); err != nil {
+				logger.Debugf("client disconnected, %v", err)
+				return
+			}
+		case <-done:
+			streams--
+		case <-client.Done():
+			logger.Debug("client disconnected")
+			return
+		case <-ticker.C:
+			if err := client.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContainerStats(t *testing.T) {
+	sample := types.StatsJSON{ID: "4f2a"}
+	sample.Read = time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	sample.PreCPUStats.CPUUsage.TotalUsage = 100
+	sample.PreCPUStats.SystemUsage = 1000
+	sample.CPUStats.CPUUsage.TotalUsage = 300
+	sample.CPUStats.CPUUsage.PercpuUsage = []uint64{150, 150}
+	sample.CPUStats.SystemUsage = 2000
+	sample.MemoryStats.Usage = 600
+	sample.MemoryStats.Limit = 1000
+	sample.MemoryStats.Stats = map[string]uint64{"cache": 100}
+	sample.PidsStats.Current = 7
+	sample.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 20},
+		"eth1": {RxBytes: 1, TxBytes: 2},
+	}
+	sample.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: 30},
+		{Op: "Write", Value: 40},
+		{Op: "Total", Value: 70},
+		{Op: "read", Value: 5},
+	}
+
+	assert.Equal(t, ContainerStats{
+		ContainerID:     "4f2a",
+		ContainerName:   "api-1",
+		Timestamp:       "2020-11-01T10:00:00Z",
+		CPUPercent:      40,
+		MemoryUsage:     500,
+		MemoryLimit:     1000,
+		MemoryPercent:   50,
+		NetworkRxBytes:  11,
+		NetworkTxBytes:  22,
+		BlockReadBytes:  35,
+		BlockWriteBytes: 40,
+		Pids:            7,
+	}, NewContainerStats("api-1", sample))
+}
+
+func TestCPUPercentWithoutPreviousSample(t *testing.T) {
+	current := types.CPUStats{SystemUsage: 1000}
+	current.CPUUsage.TotalUsage = 100
+
+	assert.Equal(t, float64(0), cpuPercent(current, current))
+	assert.Equal(t, float64(10), cpuPercent(current, types.CPUStats{}))
+}
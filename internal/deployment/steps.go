@@ -0,0 +1,97 @@
+package deployment
+
+import (
+	"context"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// pullImage pulls the image of a deployment, the pull progress is emitted as events
+func pullImage(ctx context.Context, e *EventEmitter, config Config) error {
+	return runPhase(ctx, e, PullImagePhase, func() error {
+		logger.Debugf("Pulling image for deployment %s", config.Name)
+		pullImageReader, err := docker.GetClient().PullImage(ctx, config.Registry, config.Image, config.Tag)
+		if err != nil {
+			logger.Errorf("unable to pull image %v", err)
+			return err
+		}
+		e.emitStream(pullImageReader)
+		return nil
+	})
+}
+
+// createContainers creates containers for a deployment, returns the containers created even when failing part way
+func createContainers(ctx context.Context, e *EventEmitter, config Config, count int) ([]KraneContainer, error) {
+	containersCreated := make([]KraneContainer, 0)
+	err := runPhase(ctx, e, CreateContainerPhase, func() error {
+		for i := 0; i < count; i++ {
+			c, err := ContainerCreate(ctx, config)
+			if err != nil {
+				logger.Errorf("unable to create container %v", err)
+				return err
+			}
+			containersCreated = append(containersCreated, c)
+		}
+		logger.Debugf("%d/%d container(s) for deployment %s created", len(containersCreated), count, config.Name)
+		return nil
+	})
+	return containersCreated, err
+}
+
+// startContainers starts the containers of a deployment
+func startContainers(ctx context.Context, e *EventEmitter, containers []KraneContainer) error {
+	return runPhase(ctx, e, StartContainerPhase, func() error {
+		for _, c := range containers {
+			logger.Debugf("Starting container %s", c.Name)
+			if err := c.Start(ctx); err != nil {
+				logger.Errorf("unable to start container %v", err)
+				return err
+			}
+		}
+		logger.Debugf("%d container(s) for deployment %s started", len(containers), e.Deployment)
+		return nil
+	})
+}
+
+// stopContainers stops the containers of a deployment
+func stopContainers(ctx context.Context, e *EventEmitter, containers []KraneContainer) error {
+	return runPhase(ctx, e, StopContainerPhase, func() error {
+		for _, c := range containers {
+			logger.Debugf("Stopping container %s", c.Name)
+			if err := c.Stop(ctx); err != nil {
+				logger.Errorf("unable to stop container %v", err)
+				return err
+			}
+		}
+		logger.Debugf("%d container(s) for deployment %s stopped", len(containers), e.Deployment)
+		return nil
+	})
+}
+
+// removeContainers removes the containers of a deployment as part of a phase
+func removeContainers(ctx context.Context, e *EventEmitter, phase Phase, containers []KraneContainer) error {
+	return runPhase(ctx, e, phase, func() error {
+		for _, c := range containers {
+			logger.Debugf("Removing container %s", c.Name)
+			if err := c.Remove(ctx); err != nil {
+				logger.Errorf("unable to remove container %v", err)
+				return err
+			}
+		}
+		logger.Debugf("%d container(s) for deployment %s removed", len(containers), e.Deployment)
+		return nil
+	})
+}
+
+// healthCheck health checks the containers of a deployment
+func healthCheck(ctx context.Context, e *EventEmitter, config Config, containers []KraneContainer) error {
+	return runPhase(ctx, e, HealthCheckPhase, func() error {
+		if err := RetriableContainersHealthCheck(ctx, containers, config.HealthCheck); err != nil {
+			logger.Errorf("containers did not pass health check %v", err)
+			return err
+		}
+		logger.Debugf("Deployment %s health check complete", config.Name)
+		return nil
+	})
+}
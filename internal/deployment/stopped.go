@@ -0,0 +1,21 @@
+package deployment
+
+import (
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+// IsStopped returns true if the containers of a deployment were stopped on purpose (StopContainers),
+// stopped deployments are not reconciled until their containers are started or re-created
+func IsStopped(deployment string) bool {
+	bytes, err := store.Client().Get(constants.StoppedCollectionName, deployment)
+	return err == nil && bytes != nil
+}
+
+// setStopped records whether the containers of a deployment were stopped on purpose
+func setStopped(deployment string, stopped bool) error {
+	if !stopped {
+		return store.Client().Remove(constants.StoppedCollectionName, deployment)
+	}
+	return store.Client().Put(constants.StoppedCollectionName, deployment, []byte("true"))
+}
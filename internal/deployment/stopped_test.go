@@ -0,0 +1,17 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetStopped(t *testing.T) {
+	assert.False(t, IsStopped("krane-test-stopped"))
+
+	assert.Nil(t, setStopped("krane-test-stopped", true))
+	assert.True(t, IsStopped("krane-test-stopped"))
+
+	assert.Nil(t, setStopped("krane-test-stopped", false))
+	assert.False(t, IsStopped("krane-test-stopped"))
+}
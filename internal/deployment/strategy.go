@@ -0,0 +1,167 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// StrategyType is the approach used for replacing containers during a deployment run
+type StrategyType string
+
+const (
+	// ReplaceAllStrategy creates every new container before retiring all the old ones (default)
+	ReplaceAllStrategy StrategyType = "replace_all"
+	// RollingStrategy replaces containers in batches retiring old containers as new ones become healthy
+	RollingStrategy StrategyType = "rolling"
+)
+
+// Strategy represents how containers are replaced when running a deployment
+type Strategy struct {
+	Type           StrategyType `json:"type"`            // replace_all (default) or rolling
+	MaxSurge       int          `json:"max_surge"`       // max number of containers created above the scale during a rolling update
+	MaxUnavailable int          `json:"max_unavailable"` // max number of containers that can be unavailable during a rolling update
+}
+
+// applyDefaults applies default strategy values
+func (s *Strategy) applyDefaults() {
+	if s.Type == "" {
+		s.Type = ReplaceAllStrategy
+	}
+
+	if s.Type == RollingStrategy && s.MaxSurge == 0 && s.MaxUnavailable == 0 {
+		s.MaxSurge = 1
+	}
+}
+
+// isValid returns an error if a deployment strategy is not valid
+func (s Strategy) isValid() error {
+	switch s.Type {
+	case "", ReplaceAllStrategy:
+		return nil
+	case RollingStrategy:
+		if s.MaxSurge < 0 || s.MaxUnavailable < 0 {
+			return errors.New("max_surge and max_unavailable cannot be negative")
+		}
+
+		if s.MaxSurge == 0 && s.MaxUnavailable == 0 {
+			return errors.New("max_surge and max_unavailable cannot both be 0 for a rolling strategy")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown strategy type %s", s.Type)
+	}
+}
+
+// rollingBatch is a single step of a rolling update
+type rollingBatch struct {
+	retireBefore int // old containers retired before creating new ones, bound by max unavailable
+	create       int // new containers to create and health check
+	retireAfter  int // old containers retired once the new containers are healthy
+}
+
+// planRollingUpdate returns the batches required to replace the current containers with scale new containers
+func planRollingUpdate(scale, current int, s Strategy) []rollingBatch {
+	batches := make([]rollingBatch, 0)
+
+	old := current
+	created := 0
+	for created < scale {
+		batch := rollingBatch{}
+
+		batch.retireBefore = min(s.MaxUnavailable, old)
+		old -= batch.retireBefore
+
+		remaining := scale - created
+		if old == 0 {
+			// nothing left serving traffic, create everything that's left in one go
+			batch.create = remaining
+		} else {
+			batch.create = min(remaining, max(s.MaxSurge+batch.retireBefore, 1))
+		}
+		created += batch.create
+
+		// retire enough old containers to bring the deployment back down to its scale
+		batch.retireAfter = min(old, max(old+created-scale, 0))
+		old -= batch.retireAfter
+
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// rollingUpdate replaces the containers of a deployment in batches. Every batch of new containers must pass
+// health checks before the matching old containers are retired. Retired containers are only stopped, they
+// remain part of the containers to remove once the deployment run completes. Returns the containers created.
+func rollingUpdate(ctx context.Context, e *EventEmitter, config Config, old []KraneContainer) ([]KraneContainer, error) {
+	// retire the oldest containers first
+	retirable := make([]KraneContainer, len(old))
+	copy(retirable, old)
+	sort.Slice(retirable, func(i, j int) bool { return retirable[i].CreatedAt < retirable[j].CreatedAt })
+
+	retire := func(count int) error {
+		if count == 0 {
+			return nil
+		}
+
+		if err := stopContainers(ctx, e, retirable[:count]); err != nil {
+			return err
+		}
+		retirable = retirable[count:]
+		return nil
+	}
+
+	containersCreated := make([]KraneContainer, 0)
+	batches := planRollingUpdate(config.Scale, len(old), config.Strategy)
+	for i, batch := range batches {
+		e.emit(fmt.Sprintf("Rolling update batch %d/%d started, replacing %d container(s)", i+1, len(batches), batch.create))
+
+		if err := retire(batch.retireBefore); err != nil {
+			return containersCreated, err
+		}
+
+		// create and start the new containers part of this batch
+		batchContainers, err := createContainers(ctx, e, config, batch.create)
+		containersCreated = append(containersCreated, batchContainers...)
+		if err != nil {
+			return containersCreated, err
+		}
+
+		if err := startContainers(ctx, e, batchContainers); err != nil {
+			return containersCreated, err
+		}
+
+		if err := healthCheck(ctx, e, config, batchContainers); err != nil {
+			e.emit(fmt.Sprintf("Rolling update batch %d/%d failed health check", i+1, len(batches)))
+			return containersCreated, err
+		}
+
+		if err := retire(batch.retireAfter); err != nil {
+			return containersCreated, err
+		}
+
+		e.emit(fmt.Sprintf("Rolling update batch %d/%d complete, %d/%d container(s) replaced", i+1, len(batches), len(containersCreated), config.Scale))
+	}
+
+	logger.Debugf("Rolling update for deployment %s complete", config.Name)
+	return containersCreated, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
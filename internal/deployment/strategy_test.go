@@ -0,0 +1,72 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategyDefaults(t *testing.T) {
+	s := Strategy{}
+	s.applyDefaults()
+	assert.Equal(t, ReplaceAllStrategy, s.Type)
+
+	s = Strategy{Type: RollingStrategy}
+	s.applyDefaults()
+	assert.Equal(t, 1, s.MaxSurge)
+	assert.Equal(t, 0, s.MaxUnavailable)
+}
+
+func TestInvalidStrategy(t *testing.T) {
+	assert.Nil(t, Strategy{}.isValid())
+	assert.Nil(t, Strategy{Type: RollingStrategy, MaxSurge: 1}.isValid())
+	assert.Error(t, Strategy{Type: "blue_green"}.isValid())
+	assert.Error(t, Strategy{Type: RollingStrategy}.isValid())
+	assert.Error(t, Strategy{Type: RollingStrategy, MaxSurge: -1, MaxUnavailable: 1}.isValid())
+}
+
+func TestPlanRollingUpdateWithSurge(t *testing.T) {
+	batches := planRollingUpdate(3, 3, Strategy{Type: RollingStrategy, MaxSurge: 1})
+	assert.Equal(t, []rollingBatch{
+		{retireBefore: 0, create: 1, retireAfter: 1},
+		{retireBefore: 0, create: 1, retireAfter: 1},
+		{retireBefore: 0, create: 1, retireAfter: 1},
+	}, batches)
+}
+
+func TestPlanRollingUpdateWithUnavailable(t *testing.T) {
+	batches := planRollingUpdate(4, 4, Strategy{Type: RollingStrategy, MaxUnavailable: 2})
+	assert.Equal(t, []rollingBatch{
+		{retireBefore: 2, create: 2, retireAfter: 0},
+		{retireBefore: 2, create: 2, retireAfter: 0},
+	}, batches)
+}
+
+func TestPlanRollingUpdateWithSurgeAndUnavailable(t *testing.T) {
+	batches := planRollingUpdate(5, 5, Strategy{Type: RollingStrategy, MaxSurge: 1, MaxUnavailable: 1})
+	assert.Equal(t, []rollingBatch{
+		{retireBefore: 1, create: 2, retireAfter: 1},
+		{retireBefore: 1, create: 2, retireAfter: 1},
+		{retireBefore: 1, create: 1, retireAfter: 0},
+	}, batches)
+}
+
+func TestPlanRollingUpdateWithoutExistingContainers(t *testing.T) {
+	batches := planRollingUpdate(3, 0, Strategy{Type: RollingStrategy, MaxSurge: 1})
+	assert.Equal(t, []rollingBatch{{create: 3}}, batches)
+}
+
+func TestPlanRollingUpdateWhenScalingDown(t *testing.T) {
+	batches := planRollingUpdate(2, 5, Strategy{Type: RollingStrategy, MaxSurge: 1})
+	assert.Equal(t, []rollingBatch{
+		{retireBefore: 0, create: 1, retireAfter: 4},
+		{retireBefore: 0, create: 1, retireAfter: 1},
+	}, batches)
+
+	// every old container is retired by the end of the update
+	retired := 0
+	for _, b := range batches {
+		retired += b.retireBefore + b.retireAfter
+	}
+	assert.Equal(t, 5, retired)
+}
@@ -0,0 +1,156 @@
+package deployment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// time allowed to write a message to a client
+	writeWait = 10 * time.Second
+	// time allowed to read the next pong message from a client
+	pongWait = 60 * time.Second
+	// period at which clients are pinged, must be less than the pong wait
+	pingPeriod = (pongWait * 9) / 10
+)
+
+// StreamClient is a client receiving a realtime stream of messages (events, logs...) over a
+// WebSocket or Server-Sent Events connection, subscriptions are the same for either transport.
+type StreamClient interface {
+	Send(id string, data []byte) error // Send writes a message, the id is optional and only used by SSE clients
+	Ping() error                       // Ping keeps the connection alive
+	Done() <-chan struct{}             // Done is closed once the client disconnects
+	Close() error                      // Close ends the stream and closes the connection
+}
+
+// webSocketClient streams messages to a client over a WebSocket connection
+type webSocketClient struct {
+	conn *websocket.Conn
+	done chan struct{}
+}
+
+// NewWebSocketClient returns a stream client for an upgraded WebSocket connection
+func NewWebSocketClient(conn *websocket.Conn) StreamClient {
+	c := &webSocketClient{conn: conn, done: make(chan struct{})}
+	go c.read()
+	return c
+}
+
+// read processes pongs and detects disconnects, clients are not expected to send any messages
+func (c *webSocketClient) read() {
+	defer close(c.done)
+
+	c.conn.SetReadLimit(512)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error { return c.conn.SetReadDeadline(time.Now().Add(pongWait)) })
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *webSocketClient) Send(id string, data []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *webSocketClient) Ping() error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.PingMessage, nil)
+}
+
+func (c *webSocketClient) Done() <-chan struct{} { return c.done }
+
+func (c *webSocketClient) Close() error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
+
+// sseClient streams messages to a client as Server-Sent Events (text/event-stream)
+type sseClient struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    <-chan struct{}
+	closed  bool
+}
+
+// NewSSEClient starts a Server-Sent Events response and returns a stream client for it. Messages are flushed
+// as they are sent so the stream works over HTTP/1.1 and HTTP/2, the client is done once the request is cancelled.
+func NewSSEClient(w http.ResponseWriter, r *http.Request) (StreamClient, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable response buffering in proxies
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &sseClient{w: w, flusher: flusher, done: r.Context().Done()}, nil
+}
+
+// write writes and flushes a message to the client, nothing is written once the stream is closed
+func (c *sseClient) write(fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.New("stream closed")
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+func (c *sseClient) Send(id string, data []byte) error {
+	return c.write(func() error {
+		if id != "" {
+			if _, err := fmt.Fprintf(c.w, "id: %s\n", id); err != nil {
+				return err
+			}
+		}
+
+		// every line of a message is sent as a data field
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if _, err := fmt.Fprintf(c.w, "data: %s\n", line); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprint(c.w, "\n")
+		return err
+	})
+}
+
+func (c *sseClient) Ping() error {
+	// comments are ignored by SSE clients
+	return c.write(func() error {
+		_, err := fmt.Fprint(c.w, ": ping\n\n")
+		return err
+	})
+}
+
+func (c *sseClient) Done() <-chan struct{} { return c.done }
+
+// Close ends the stream, the response is completed once the handler serving the stream returns
+func (c *sseClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	return nil
+}
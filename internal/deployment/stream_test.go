@@ -0,0 +1,96 @@
+package deployment
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSEClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		client, err := NewSSEClient(w, r)
+		assert.Nil(t, err)
+
+		assert.Nil(t, client.Send("1", []byte(`{"message":"PULL_IMAGE started"}`)))
+		assert.Nil(t, client.Ping())
+		assert.Nil(t, client.Send("", []byte("first line\nsecond line")))
+		assert.Nil(t, client.Close())
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	assert.Equal(t, []string{
+		"id: 1",
+		`data: {"message":"PULL_IMAGE started"}`,
+		"",
+		": ping",
+		"",
+		"data: first line",
+		"data: second line",
+		"",
+	}, lines)
+}
+
+func TestSSEClientDisconnect(t *testing.T) {
+	disconnected := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := NewSSEClient(w, r)
+		assert.Nil(t, err)
+
+		<-client.Done()
+		disconnected <- true
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Body.Close())
+
+	assert.True(t, <-disconnected)
+}
+
+func TestSSEClientOverHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := NewSSEClient(w, r)
+		assert.Nil(t, err)
+
+		assert.Nil(t, client.Send("1", []byte("hello")))
+		assert.Nil(t, client.Close())
+		assert.Error(t, client.Send("2", []byte("closed")))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Equal(t, []string{"id: 1", "data: hello", ""}, lines)
+}
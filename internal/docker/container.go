@@ -1,9 +1,7 @@
 package docker
 
 import (
-	"bufio"
 	"context"
-	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -28,12 +26,20 @@ type DockerConfig struct {
 	Env           []string // Comma separated, formatted NODE_ENV=dev
 	Command       []string
 	Entrypoint    []string
+	HealthCheck   *container.HealthConfig
+	Resources     container.Resources
+	RestartPolicy container.RestartPolicy
+	StopTimeout   *int // seconds
+	StopSignal    string
 }
 
+// DefaultStopTimeout is the time given to a container to stop when it has no stop timeout configured
+const DefaultStopTimeout = 60 * time.Second
+
 // CreateContainer creates a docker container from a docker config
 func (c *Client) CreateContainer(ctx context.Context, config DockerConfig) (container.ContainerCreateCreatedBody, error) {
 	networkingConfig := createNetworkingConfig(config.NetworkID)
-	hostConfig := createHostConfig(config.Ports, config.VolumeMounts)
+	hostConfig := createHostConfig(config.Ports, config.VolumeMounts, config.Resources, config.RestartPolicy)
 	containerConfig := createContainerConfig(config.ContainerName,
 		config.Image,
 		config.Env,
@@ -41,7 +47,10 @@ func (c *Client) CreateContainer(ctx context.Context, config DockerConfig) (cont
 		config.Command,
 		config.Entrypoint,
 		config.VolumeSet,
-		config.PortSet)
+		config.PortSet,
+		config.HealthCheck,
+		config.StopTimeout,
+		config.StopSignal)
 
 	return c.ContainerCreate(
 		ctx,
@@ -58,9 +67,8 @@ func (c *Client) StartContainer(ctx context.Context, containerID string) error {
 	return c.ContainerStart(ctx, containerID, options)
 }
 
-// StopContainer : stop docker container
-func (c *Client) StopContainer(ctx context.Context, containerID string) error {
-	timeout := 60 * time.Second
+// StopContainer : stop docker container, killing it if it has not stopped after the timeout
+func (c *Client) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
 	return c.ContainerStop(ctx, containerID, &timeout)
 }
 
@@ -107,63 +115,6 @@ func (c *Client) GetContainerStatus(ctx context.Context, containerID string, str
 	return c.ContainerStats(ctx, containerID, stream)
 }
 
-// StreamContainerLogs reads the logs for a container outputting the data into a unbuffered channel
-func (c *Client) StreamContainerLogs(containerID string, out chan []byte, done chan bool) error {
-	ctx := context.Background()
-	defer ctx.Done()
-
-	stream, err := c.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Timestamps: true,
-		Follow:     true,
-		Tail:       "200",
-	})
-
-	if err != nil {
-		if stream != nil {
-			if err := stream.Close(); err != nil {
-				return err
-			}
-		}
-		return err
-	}
-
-	reader := bufio.NewReader(stream)
-	var mu sync.RWMutex
-	go func() {
-		for {
-			mu.Lock()
-
-			// read the first 8 bytes to ignore the HEADER part from docker container logs
-			header := make([]byte, 8)
-			_, err := reader.Read(header)
-			if err != nil {
-				if err := stream.Close(); err != nil {
-					return
-				}
-				done <- true
-				return
-			}
-
-			bytes, _, err := reader.ReadLine()
-			if err != nil {
-				if err := stream.Close(); err != nil {
-					return
-				}
-				done <- true
-				return
-			}
-
-			out <- bytes
-
-			mu.Unlock()
-		}
-	}()
-
-	return nil
-}
-
 // ConnectContainerToNetwork connects a container to a docker network
 func (c *Client) ConnectContainerToNetwork(ctx *context.Context, networkID string, containerID string) (err error) {
 	config := network.EndpointSettings{NetworkID: networkID}
@@ -179,7 +130,10 @@ func createContainerConfig(
 	command []string,
 	entrypoint []string,
 	volumes map[string]struct{},
-	ports nat.PortSet) container.Config {
+	ports nat.PortSet,
+	healthcheck *container.HealthConfig,
+	stopTimeout *int,
+	stopSignal string) container.Config {
 	config := container.Config{
 		Hostname:     hostname,
 		Image:        image,
@@ -187,6 +141,8 @@ func createContainerConfig(
 		Labels:       labels,
 		Volumes:      volumes,
 		ExposedPorts: ports,
+		StopTimeout:  stopTimeout,
+		StopSignal:   stopSignal,
 	}
 
 	if len(command) > 0 {
@@ -197,14 +153,20 @@ func createContainerConfig(
 		config.Entrypoint = entrypoint
 	}
 
+	if healthcheck != nil {
+		config.Healthcheck = healthcheck
+	}
+
 	return config
 }
 
 // createHostConfig returns the host config for a Docker container
-func createHostConfig(ports nat.PortMap, volumes []mount.Mount) container.HostConfig {
+func createHostConfig(ports nat.PortMap, volumes []mount.Mount, resources container.Resources, restartPolicy container.RestartPolicy) container.HostConfig {
 	return container.HostConfig{
-		PortBindings: ports,
-		AutoRemove:   false,
-		Mounts:       volumes,
+		PortBindings:  ports,
+		AutoRemove:    false,
+		Mounts:        volumes,
+		Resources:     resources,
+		RestartPolicy: restartPolicy,
 	}
 }
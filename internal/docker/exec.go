@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// RunContainerCommand executes a command inside a running container and waits for it to exit returning its exit code
+func (c *Client) RunContainerCommand(ctx context.Context, containerID string, cmd []string) (int, error) {
+	exec, err := c.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:    cmd,
+		Detach: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.ContainerExecStart(ctx, exec.ID, types.ExecStartCheck{Detach: true}); err != nil {
+		return 0, err
+	}
+
+	// poll the exec instance until the command exits or the context is done
+	for {
+		inspect, err := c.ContainerExecInspect(ctx, exec.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// CreateExec creates an exec instance running a command in a container, stdin, stdout and stderr are attached
+func (c *Client) CreateExec(ctx context.Context, containerID string, cmd []string, tty bool, env []string, user string) (string, error) {
+	resp, err := c.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		Tty:          tty,
+		Env:          env,
+		User:         user,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+// AttachExec starts an exec instance and returns the connection attached to its standard streams.
+// Output of exec instances without a TTY is multiplexed the same way as container logs.
+func (c *Client) AttachExec(ctx context.Context, execID string, tty bool) (types.HijackedResponse, error) {
+	return c.ContainerExecAttach(ctx, execID, types.ExecConfig{Tty: tty})
+}
+
+// ResizeExec resizes the TTY of an exec instance
+func (c *Client) ResizeExec(ctx context.Context, execID string, width uint, height uint) error {
+	return c.ContainerExecResize(ctx, execID, types.ResizeOptions{Width: width, Height: height})
+}
+
+// GetExecExitCode returns the exit code of an exec instance, returns false if the command is still running
+func (c *Client) GetExecExitCode(ctx context.Context, execID string) (int, bool, error) {
+	inspect, err := c.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return inspect.ExitCode, !inspect.Running, nil
+}
+
+// DemuxExecOutput splits the output of an exec instance into stdout and stderr
+func DemuxExecOutput(output io.Reader, tty bool, stdout io.Writer, stderr io.Writer) error {
+	return demuxLogs(output, tty, stdout, stderr)
+}
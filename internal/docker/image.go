@@ -9,10 +9,7 @@ import (
 )
 
 // PullImage pulls a container image from a registry onto the host machine
-func (c *Client) PullImage(registry, image, tag string) (io.Reader, error) {
-	ctx := context.Background()
-	defer ctx.Done()
-
+func (c *Client) PullImage(ctx context.Context, registry, image, tag string) (io.Reader, error) {
 	ref := createImageRef(registry, image, tag)
 	return c.ImagePull(ctx, ref, types.ImagePullOptions{
 		All:          false,
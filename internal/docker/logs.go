@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	StdoutStream = "stdout"
+	StderrStream = "stderr"
+)
+
+// ContainerLogLine is a single line of container logs
+type ContainerLogLine struct {
+	ContainerID string
+	Stream      string // stdout or stderr, logs of TTY containers are always stdout
+	Line        string // line prefixed by its RFC3339Nano timestamp
+}
+
+// StreamContainerLogs follows the logs of a container sending every line into a unbuffered channel.
+// Logs are streamed in the background until the context is done or the container stops, done is
+// signaled once the stream ends. Since and tail select the logs sent before following and are passed
+// down to Docker as is.
+func (c *Client) StreamContainerLogs(ctx context.Context, containerID string, since string, tail string, out chan<- ContainerLogLine, done chan<- bool) error {
+	container, err := c.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	stream, err := c.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Follow:     true,
+		Since:      since,
+		Tail:       tail,
+	})
+	if err != nil {
+		return err
+	}
+
+	send := func(stream string) *logLineWriter {
+		return &logLineWriter{fn: func(line string) error {
+			select {
+			case out <- ContainerLogLine{ContainerID: containerID, Stream: stream, Line: line}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}}
+	}
+
+	go func() {
+		defer func() {
+			_ = stream.Close()
+			select {
+			case done <- true:
+			case <-ctx.Done():
+			}
+		}()
+
+		tty := container.Config != nil && container.Config.Tty
+		_ = demuxLogs(stream, tty, send(StdoutStream), send(StderrStream))
+	}()
+
+	return nil
+}
+
+// ReadContainerLogs returns the logs of a container (without following them) demultiplexed into stdout and stderr.
+// Every line is prefixed by its RFC3339Nano timestamp, since and tail are passed down to Docker as is.
+func (c *Client) ReadContainerLogs(ctx context.Context, containerID string, stdout bool, stderr bool, since string, tail string) (stdoutLogs []byte, stderrLogs []byte, err error) {
+	container, err := c.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream, err := c.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: stdout,
+		ShowStderr: stderr,
+		Timestamps: true,
+		Since:      since,
+		Tail:       tail,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stream.Close()
+
+	var outBuf, errBuf bytes.Buffer
+	tty := container.Config != nil && container.Config.Tty
+	if err := demuxLogs(stream, tty, &outBuf, &errBuf); err != nil {
+		return nil, nil, err
+	}
+
+	return outBuf.Bytes(), errBuf.Bytes(), nil
+}
+
+// demuxLogs splits a Docker logs stream into stdout and stderr. Logs of containers without a TTY are
+// multiplexed into frames prefixed by a header identifying the stream, logs of TTY containers are raw.
+func demuxLogs(stream io.Reader, tty bool, stdout io.Writer, stderr io.Writer) error {
+	var err error
+	if tty {
+		_, err = io.Copy(stdout, stream)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, stream)
+	}
+
+	// flush partial lines left at the end of the stream
+	for _, w := range []io.Writer{stdout, stderr} {
+		if lw, ok := w.(*logLineWriter); ok {
+			if flushErr := lw.flush(); err == nil {
+				err = flushErr
+			}
+		}
+	}
+
+	return err
+}
+
+// logLineWriter splits the data written into lines, a line can span multiple writes
+type logLineWriter struct {
+	buf []byte
+	fn  func(line string) error
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		line := string(bytes.TrimSuffix(w.buf[:i], []byte("\r")))
+		w.buf = w.buf[i+1:]
+		if err := w.fn(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// flush writes the remaining partial line (if any)
+func (w *logLineWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := string(w.buf)
+	w.buf = nil
+	return w.fn(line)
+}
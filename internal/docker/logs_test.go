@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDemuxMultiplexedLogs(t *testing.T) {
+	var stream bytes.Buffer
+	stdout := stdcopy.NewStdWriter(&stream, stdcopy.Stdout)
+	stderr := stdcopy.NewStdWriter(&stream, stdcopy.Stderr)
+
+	// a frame can contain multiple lines and a line can span multiple frames
+	_, _ = stdout.Write([]byte("2020-11-03T20:00:00Z first\n2020-11-03T20:00:01Z sec"))
+	_, _ = stderr.Write([]byte("2020-11-03T20:00:01Z error\n"))
+	_, _ = stdout.Write([]byte("ond\r\n2020-11-03T20:00:02Z partial"))
+
+	lines := make([]ContainerLogLine, 0)
+	writer := func(stream string) *logLineWriter {
+		return &logLineWriter{fn: func(line string) error {
+			lines = append(lines, ContainerLogLine{Stream: stream, Line: line})
+			return nil
+		}}
+	}
+
+	assert.Nil(t, demuxLogs(&stream, false, writer(StdoutStream), writer(StderrStream)))
+	assert.Equal(t, []ContainerLogLine{
+		{Stream: StdoutStream, Line: "2020-11-03T20:00:00Z first"},
+		{Stream: StderrStream, Line: "2020-11-03T20:00:01Z error"},
+		{Stream: StdoutStream, Line: "2020-11-03T20:00:01Z second"},
+		{Stream: StdoutStream, Line: "2020-11-03T20:00:02Z partial"},
+	}, lines)
+}
+
+func TestDemuxTTYLogs(t *testing.T) {
+	stream := bytes.NewBufferString("2020-11-03T20:00:00Z first\n2020-11-03T20:00:01Z second\n")
+
+	var stdout, stderr bytes.Buffer
+	assert.Nil(t, demuxLogs(stream, true, &stdout, &stderr))
+	assert.Equal(t, "2020-11-03T20:00:00Z first\n2020-11-03T20:00:01Z second\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ReadContainerStats returns a single sample of the resource usage stats of a container
+func (c *Client) ReadContainerStats(ctx context.Context, containerID string) (types.StatsJSON, error) {
+	stats, err := c.GetContainerStatus(ctx, containerID, false)
+	if err != nil {
+		return types.StatsJSON{}, err
+	}
+	defer stats.Body.Close()
+
+	var sample types.StatsJSON
+	if err := json.NewDecoder(stats.Body).Decode(&sample); err != nil {
+		return types.StatsJSON{}, err
+	}
+
+	// older Docker APIs do not include the container id in samples
+	sample.ID = containerID
+	return sample, nil
+}
+
+// StreamContainerStats follows the resource usage stats of a container sending every sample (about one per second)
+// into a unbuffered channel. Stats are streamed in the background until the context is done or the container stops,
+// done is signaled once the stream ends.
+func (c *Client) StreamContainerStats(ctx context.Context, containerID string, out chan<- types.StatsJSON, done chan<- bool) error {
+	stats, err := c.GetContainerStatus(ctx, containerID, true)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() {
+			_ = stats.Body.Close()
+			select {
+			case done <- true:
+			case <-ctx.Done():
+			}
+		}()
+
+		_ = decodeStats(stats.Body, func(sample types.StatsJSON) error {
+			sample.ID = containerID
+			select {
+			case out <- sample:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return nil
+}
+
+// decodeStats decodes a stream of stats samples until the stream ends
+func decodeStats(stream io.Reader, fn func(sample types.StatsJSON) error) error {
+	decoder := json.NewDecoder(stream)
+	for {
+		var sample types.StatsJSON
+		if err := decoder.Decode(&sample); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := fn(sample); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeStatsStream(t *testing.T) {
+	stream := strings.NewReader(`{"pids_stats":{"current":3}}
+{"pids_stats":{"current":4}}
+`)
+
+	samples := make([]types.StatsJSON, 0)
+	assert.Nil(t, decodeStats(stream, func(sample types.StatsJSON) error {
+		samples = append(samples, sample)
+		return nil
+	}))
+
+	assert.Len(t, samples, 2)
+	assert.Equal(t, uint64(3), samples[0].PidsStats.Current)
+	assert.Equal(t, uint64(4), samples[1].PidsStats.Current)
+}
+
+func TestDecodeStatsStopsOnError(t *testing.T) {
+	stream := strings.NewReader(`{"pids_stats":{"current":3}} {"pids_stats":{"current":4}}`)
+
+	calls := 0
+	err := decodeStats(stream, func(sample types.StatsJSON) error {
+		calls++
+		return errors.New("client disconnected")
+	})
+
+	assert.EqualError(t, err, "client disconnected")
+	assert.Equal(t, 1, calls)
+}
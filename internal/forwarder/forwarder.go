@@ -0,0 +1,231 @@
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// Forwarder follows the logs of the containers of every deployment configured with log sinks
+// and forwards them to those sinks. Containers and sink configurations are polled on an interval.
+type Forwarder struct {
+	interval    time.Duration
+	startedAt   time.Time
+	mu          *sync.Mutex
+	deployments map[string]*deploymentForwarder
+}
+
+// deploymentForwarder forwards the logs of the containers of a single deployment
+type deploymentForwarder struct {
+	name    string
+	config  []deployment.LogSink
+	sinks   []*bufferedSink
+	mu      sync.Mutex
+	follows map[string]*follow // containers being followed by id
+}
+
+// follow is a container whose logs are followed
+type follow struct {
+	cancel   context.CancelFunc
+	done     bool
+	lastSeen time.Time // timestamp of the last line forwarded, logs are resumed from there
+}
+
+// New returns a new log forwarder polling deployments for log sinks on an interval
+func New(interval_ms string) Forwarder {
+	interval, _ := time.ParseDuration(interval_ms + "ms")
+	return Forwarder{
+		interval:    interval,
+		startedAt:   time.Now(),
+		mu:          &sync.Mutex{},
+		deployments: make(map[string]*deploymentForwarder),
+	}
+}
+
+// Run starts the forwarder polling on an interval
+func (f *Forwarder) Run() {
+	logger.Debug("Starting log forwarder")
+
+	for {
+		f.poll()
+		<-time.After(f.interval)
+	}
+}
+
+// poll starts following the logs of new containers of deployments with log sinks
+// and stops forwarding logs for deployments that no longer have any sinks
+func (f *Forwarder) poll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	configs, err := deployment.GetAllDeploymentConfigs()
+	if err != nil {
+		logger.Errorf("Log forwarder unable to get deployments, %v", err)
+		return
+	}
+
+	active := make(map[string]bool)
+	for _, config := range configs {
+		if len(config.LogSinks) == 0 {
+			continue
+		}
+		active[config.Name] = true
+
+		d, ok := f.deployments[config.Name]
+		if !ok {
+			d = &deploymentForwarder{name: config.Name, follows: make(map[string]*follow)}
+			f.deployments[config.Name] = d
+		}
+
+		d.configure(config.LogSinks)
+
+		containers, err := deployment.GetContainersByDeployment(config.Name)
+		if err != nil {
+			logger.Warnf("Log forwarder unable to get containers for deployment %s, %v", config.Name, err)
+			continue
+		}
+
+		d.follow(containers, f.startedAt)
+	}
+
+	for name, d := range f.deployments {
+		if !active[name] {
+			logger.Debugf("Stopping log forwarding for deployment %s", name)
+			d.stop()
+			delete(f.deployments, name)
+		}
+	}
+}
+
+// configure (re)creates the sinks of a deployment when its sink configuration changed
+func (d *deploymentForwarder) configure(config []deployment.LogSink) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sinks != nil && reflect.DeepEqual(d.config, config) {
+		return
+	}
+
+	for _, s := range d.sinks {
+		s.close()
+	}
+
+	d.config = config
+	d.sinks = make([]*bufferedSink, 0, len(config))
+	for _, sink := range config {
+		d.sinks = append(d.sinks, newBufferedSink(newSinkWriter(d.name, sink), sink.BufferSize))
+	}
+}
+
+// follow starts following the logs of running containers not already followed. Logs of a new container are
+// followed from its creation, logs of containers running before Krane started are followed from when Krane
+// started so lines are not forwarded twice across restarts.
+func (d *deploymentForwarder) follow(containers []deployment.KraneContainer, startedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	running := make(map[string]bool)
+	for _, c := range containers {
+		if !c.State.Running {
+			continue
+		}
+		running[c.ID] = true
+
+		fw, ok := d.follows[c.ID]
+		if ok && !fw.done {
+			continue
+		}
+
+		if !ok {
+			since := time.Unix(c.CreatedAt, 0)
+			if since.Before(startedAt) {
+				since = startedAt
+			}
+			fw = &follow{lastSeen: since}
+			d.follows[c.ID] = fw
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		fw.cancel = cancel
+		fw.done = false
+		go d.forward(ctx, c, fw)
+	}
+
+	// forget containers which were removed
+	for id, fw := range d.follows {
+		if !running[id] && fw.done {
+			delete(d.follows, id)
+		}
+	}
+}
+
+// forward sends the log lines of a container to every sink of the deployment until the container stops
+func (d *deploymentForwarder) forward(ctx context.Context, c deployment.KraneContainer, fw *follow) {
+	defer func() {
+		d.mu.Lock()
+		fw.cancel()
+		fw.done = true
+		d.mu.Unlock()
+	}()
+
+	d.mu.Lock()
+	since := fw.lastSeen
+	d.mu.Unlock()
+
+	out := make(chan docker.ContainerLogLine)
+	done := make(chan bool)
+	dockerSince := fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	if err := docker.GetClient().StreamContainerLogs(ctx, c.ID, dockerSince, "all", out, done); err != nil {
+		logger.Warnf("Log forwarder unable to follow logs for container %s, %v", c.Name, err)
+		return
+	}
+
+	for {
+		select {
+		case l := <-out:
+			message := deployment.NewLogMessage(l, c.Name)
+
+			d.mu.Lock()
+			if timestamp, err := time.Parse(time.RFC3339Nano, message.Timestamp); err == nil {
+				// docker includes lines at the since time, resume right after the last line
+				if !timestamp.After(fw.lastSeen) {
+					d.mu.Unlock()
+					continue
+				}
+				fw.lastSeen = timestamp
+			}
+
+			for _, s := range d.sinks {
+				s.enqueue(message)
+			}
+			d.mu.Unlock()
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stop stops following containers and closes the sinks of a deployment
+func (d *deploymentForwarder) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, fw := range d.follows {
+		if fw.cancel != nil {
+			fw.cancel()
+		}
+	}
+
+	for _, s := range d.sinks {
+		s.close()
+	}
+	d.sinks = nil
+}
@@ -0,0 +1,135 @@
+package forwarder
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/logger"
+)
+
+// max log messages written to a sink at once
+const maxBatchSize = 100
+
+var (
+	// initial delay before retrying to write to an unavailable sink, doubled after every failure
+	retryBackoff = time.Second
+	// max delay between retries
+	maxRetryBackoff = 30 * time.Second
+)
+
+// sinkWriter writes log messages to an external destination. Write returns the amount of messages
+// written, messages are written in order so a failed batch is retried from the first message not written.
+type sinkWriter interface {
+	Write(messages []deployment.LogMessage) (int, error)
+	Close() error
+}
+
+// bufferedSink buffers log messages while they are written to a sink in the background. Writes are
+// retried with an exponential backoff while the sink is unavailable, messages are dropped once the buffer is full.
+type bufferedSink struct {
+	dropped  uint64 // first field so it is 64-bit aligned for atomic operations
+	writer   sinkWriter
+	messages chan deployment.LogMessage
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// newBufferedSink starts writing log messages to a sink in the background
+func newBufferedSink(writer sinkWriter, size int) *bufferedSink {
+	s := &bufferedSink{
+		writer:   writer,
+		messages: make(chan deployment.LogMessage, size),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// enqueue buffers a log message without blocking, the message is dropped if the buffer is full
+func (s *bufferedSink) enqueue(message deployment.LogMessage) {
+	select {
+	case s.messages <- message:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// run writes buffered log messages in batches until the sink is closed
+func (s *bufferedSink) run() {
+	defer close(s.stopped)
+
+	for {
+		batch, ok := s.next()
+		if !ok {
+			return
+		}
+
+		if !s.write(batch) {
+			return
+		}
+
+		if dropped := atomic.SwapUint64(&s.dropped, 0); dropped > 0 {
+			logger.Warnf("Log sink buffer full, dropped %d log messages", dropped)
+		}
+	}
+}
+
+// next blocks until log messages are buffered and returns up to a batch of them, returns false once the sink is closed
+func (s *bufferedSink) next() ([]deployment.LogMessage, bool) {
+	var batch []deployment.LogMessage
+	select {
+	case m := <-s.messages:
+		batch = append(batch, m)
+	case <-s.stop:
+		return nil, false
+	}
+
+	for len(batch) < maxBatchSize {
+		select {
+		case m := <-s.messages:
+			batch = append(batch, m)
+		default:
+			return batch, true
+		}
+	}
+
+	return batch, true
+}
+
+// write writes a batch of log messages retrying until it succeeds, returns false if the sink was closed while retrying.
+// Retries resume after the messages already written so they are not written twice.
+func (s *bufferedSink) write(batch []deployment.LogMessage) bool {
+	backoff := retryBackoff
+	for {
+		written, err := s.writer.Write(batch)
+		if err == nil {
+			return true
+		}
+		batch = batch[written:]
+
+		logger.Warnf("Unable to write to log sink, retrying in %s, %v", backoff.String(), err)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			return false
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// close stops writing log messages and closes the sink, buffered messages not yet written are discarded
+func (s *bufferedSink) close() {
+	close(s.stop)
+	<-s.stopped
+
+	if err := s.writer.Close(); err != nil {
+		logger.Warnf("Error closing log sink, %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package forwarder
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/deployment"
+)
+
+// flakyWriter fails a number of writes before succeeding, failed writes still write up to partial messages
+type flakyWriter struct {
+	mu       sync.Mutex
+	failures int
+	partial  int
+	attempts int
+	written  []deployment.LogMessage
+}
+
+func (w *flakyWriter) Write(messages []deployment.LogMessage) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.attempts++
+	if w.attempts <= w.failures {
+		n := w.partial
+		if n > len(messages) {
+			n = len(messages)
+		}
+		w.written = append(w.written, messages[:n]...)
+		return n, errors.New("sink unavailable")
+	}
+
+	w.written = append(w.written, messages...)
+	return len(messages), nil
+}
+
+func (w *flakyWriter) Close() error { return nil }
+
+func (w *flakyWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.written)
+}
+
+func withRetryBackoff(t *testing.T, d time.Duration) {
+	initial, max := retryBackoff, maxRetryBackoff
+	retryBackoff, maxRetryBackoff = d, d
+	t.Cleanup(func() { retryBackoff, maxRetryBackoff = initial, max })
+}
+
+func TestBufferedSinkRetriesUnavailableSink(t *testing.T) {
+	withRetryBackoff(t, time.Millisecond)
+
+	writer := &flakyWriter{failures: 3}
+	sink := newBufferedSink(writer, 10)
+	defer sink.close()
+
+	sink.enqueue(deployment.LogMessage{Line: "hello"})
+	sink.enqueue(deployment.LogMessage{Line: "world"})
+
+	assert.Eventually(t, func() bool { return writer.count() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "hello", writer.written[0].Line)
+	assert.Equal(t, "world", writer.written[1].Line)
+}
+
+func TestBufferedSinkResumesPartiallyWrittenBatches(t *testing.T) {
+	withRetryBackoff(t, time.Millisecond)
+
+	writer := &flakyWriter{failures: 2, partial: 1}
+
+	// the batch is written before the sink starts so the messages are part of a single batch
+	sink := &bufferedSink{writer: writer, stop: make(chan struct{})}
+	assert.True(t, sink.write([]deployment.LogMessage{{Line: "one"}, {Line: "two"}, {Line: "three"}}))
+
+	assert.Len(t, writer.written, 3)
+	assert.Equal(t, "one", writer.written[0].Line)
+	assert.Equal(t, "two", writer.written[1].Line)
+	assert.Equal(t, "three", writer.written[2].Line)
+}
+
+func TestBufferedSinkDropsMessagesWhenFull(t *testing.T) {
+	withRetryBackoff(t, time.Hour)
+
+	// the first batch is stuck retrying so later messages fill the buffer
+	writer := &flakyWriter{failures: 1}
+	sink := newBufferedSink(writer, 2)
+
+	sink.enqueue(deployment.LogMessage{Line: "first"})
+	assert.Eventually(t, func() bool {
+		writer.mu.Lock()
+		defer writer.mu.Unlock()
+		return writer.attempts == 1
+	}, time.Second, time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		sink.enqueue(deployment.LogMessage{Line: "next"})
+	}
+
+	assert.Len(t, sink.messages, 2)
+	assert.Equal(t, uint64(3), sink.dropped)
+
+	// closing does not wait for the retry backoff
+	sink.close()
+}
@@ -0,0 +1,258 @@
+package forwarder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/docker"
+)
+
+const (
+	sinkDialTimeout    = 5 * time.Second
+	sinkRequestTimeout = 10 * time.Second
+)
+
+// newSinkWriter returns the writer for a deployment log sink
+func newSinkWriter(deploymentName string, sink deployment.LogSink) sinkWriter {
+	switch sink.Type {
+	case deployment.SyslogLogSink:
+		return &syslogWriter{protocol: sink.Protocol, address: sink.Address, app: deploymentName}
+	case deployment.HTTPLogSink:
+		return &httpWriter{url: sink.URL, headers: sink.Headers, client: &http.Client{Timeout: sinkRequestTimeout}}
+	default:
+		return &fileWriter{
+			path:     sink.FilePath(deploymentName),
+			maxSize:  int64(sink.MaxSizeMb) * 1024 * 1024,
+			maxFiles: sink.MaxFiles,
+		}
+	}
+}
+
+// syslogWriter writes log messages to a syslog server in the RFC5424 format, the hostname is
+// the container name and the app name is the deployment name. Messages are newline framed over tcp.
+type syslogWriter struct {
+	protocol string
+	address  string
+	app      string
+	conn     net.Conn
+}
+
+func (w *syslogWriter) Write(messages []deployment.LogMessage) (int, error) {
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.protocol, w.address, sinkDialTimeout)
+		if err != nil {
+			return 0, err
+		}
+		w.conn = conn
+	}
+
+	for i, m := range messages {
+		line := formatSyslogMessage(w.app, m)
+		if w.protocol == "tcp" {
+			line += "\n"
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(sinkDialTimeout))
+		if _, err := w.conn.Write([]byte(line)); err != nil {
+			// reconnect on the next write
+			_ = w.conn.Close()
+			w.conn = nil
+			return i, err
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (w *syslogWriter) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.Close()
+}
+
+// formatSyslogMessage formats a log message as an RFC5424 syslog message. Stdout lines are
+// sent with the informational severity, stderr lines with the error severity.
+func formatSyslogMessage(app string, m deployment.LogMessage) string {
+	// facility user (1) * 8 + severity
+	priority := 8 + 6
+	if m.Stream == docker.StderrStream {
+		priority = 8 + 3
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		priority,
+		timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(m.ContainerName),
+		syslogField(app),
+		m.Stream,
+		m.Line)
+}
+
+// syslogField returns the nil value for empty header fields
+func syslogField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// httpWriter posts batches of log messages as a JSON array to an http endpoint
+type httpWriter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (w *httpWriter) Write(messages []deployment.LogMessage) (int, error) {
+	body, err := json.Marshal(messages)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// batches are posted as a whole, none of the messages are considered written when the post fails
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return len(messages), nil
+}
+
+func (w *httpWriter) Close() error { return nil }
+
+// fileWriter appends log messages as JSON lines to a local file. The file is rotated once it
+// reaches its max size, rotated files are suffixed by their index (ie. app.log.1, app.log.2).
+type fileWriter struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func (w *fileWriter) Write(messages []deployment.LogMessage) (int, error) {
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, m := range messages {
+		line, err := json.Marshal(m)
+		if err != nil {
+			return i, err
+		}
+		line = append(line, '\n')
+
+		if w.size > 0 && w.size+int64(len(line)) > w.maxSize {
+			if err := w.rotate(); err != nil {
+				return i, err
+			}
+		}
+
+		if err := w.writeLine(line); err != nil {
+			return i, err
+		}
+	}
+
+	return len(messages), nil
+}
+
+// writeLine appends a line to the log file, a partially written line is truncated
+// so the line can be written again without leaving a corrupted line behind
+func (w *fileWriter) writeLine(line []byte) error {
+	n, err := w.file.Write(line)
+	if err == nil {
+		w.size += int64(n)
+		return nil
+	}
+
+	if n > 0 {
+		if truncateErr := w.file.Truncate(w.size); truncateErr != nil {
+			// the file is reopened on the next write to pick up its actual size
+			_ = w.file.Close()
+			w.file = nil
+		}
+	}
+	return err
+}
+
+// open opens the log file in append mode creating it (and its directory) if needed
+func (w *fileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files by one, removing the oldest, and starts a new log file
+func (w *fileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if w.maxFiles > 0 {
+		_ = os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxFiles))
+		for i := w.maxFiles - 1; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+		}
+
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+func (w *fileWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,157 @@
+package forwarder
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/deployment"
+)
+
+var testMessage = deployment.LogMessage{
+	ContainerID:   "4f2a",
+	ContainerName: "api-1",
+	Stream:        "stdout",
+	Timestamp:     "2020-11-01T10:00:00.123456789Z",
+	Line:          "listening on :8080",
+}
+
+func TestFormatSyslogMessage(t *testing.T) {
+	assert.Equal(t, "<14>1 2020-11-01T10:00:00.123456Z api-1 api - stdout - listening on :8080", formatSyslogMessage("api", testMessage))
+
+	stderr := testMessage
+	stderr.Stream = "stderr"
+	stderr.ContainerName = ""
+	assert.Equal(t, "<11>1 2020-11-01T10:00:00.123456Z - api - stderr - listening on :8080", formatSyslogMessage("api", stderr))
+}
+
+func TestSyslogWriterOverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	writer := newSinkWriter("api", deployment.LogSink{Type: deployment.SyslogLogSink, Protocol: "tcp", Address: listener.Addr().String()})
+	defer writer.Close()
+
+	_, err = writer.Write([]deployment.LogMessage{testMessage, testMessage})
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		select {
+		case line := <-lines:
+			assert.Equal(t, formatSyslogMessage("api", testMessage), line)
+		case <-time.After(time.Second):
+			t.Fatal("syslog message not received")
+		}
+	}
+}
+
+func TestHTTPWriter(t *testing.T) {
+	var received []deployment.LogMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	writer := newSinkWriter("api", deployment.LogSink{
+		Type:    deployment.HTTPLogSink,
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+
+	written, err := writer.Write([]deployment.LogMessage{testMessage})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, written)
+	assert.Equal(t, []deployment.LogMessage{testMessage}, received)
+}
+
+func TestHTTPWriterErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	writer := newSinkWriter("api", deployment.LogSink{Type: deployment.HTTPLogSink, URL: server.URL})
+	written, err := writer.Write([]deployment.LogMessage{testMessage})
+	assert.Equal(t, 0, written)
+	assert.EqualError(t, err, "unexpected response status 503 Service Unavailable")
+}
+
+func TestFileWriterRotates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "krane-log-sink")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	line, _ := json.Marshal(testMessage)
+	size := int64(len(line) + 1)
+
+	path := filepath.Join(dir, "logs", "api.log")
+	writer := &fileWriter{path: path, maxSize: 2 * size, maxFiles: 2}
+	defer writer.Close()
+
+	// 2 messages per file, the oldest file is removed once there are more than 2 rotated files
+	for i := 0; i < 7; i++ {
+		_, err = writer.Write([]deployment.LogMessage{testMessage})
+		assert.Nil(t, err)
+	}
+
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(file)
+		assert.Nil(t, err)
+		if file == path {
+			assert.Equal(t, size, info.Size())
+		} else {
+			assert.Equal(t, 2*size, info.Size())
+		}
+	}
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(line)+"\n", string(contents))
+}
+
+func TestFileSinkWriterIsConfinedToTheLogSinkDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "krane-log-sinks")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(constants.EnvLogSinkDir, dir)
+	defer os.Unsetenv(constants.EnvLogSinkDir)
+
+	writer := newSinkWriter("api", deployment.LogSink{Type: deployment.FileLogSink, Path: "logs/app.log"})
+	_, err = writer.Write([]deployment.LogMessage{testMessage})
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "api", "logs", "app.log"))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,108 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCancelled is recorded on jobs which were cancelled before they could finish
+var ErrCancelled = errors.New("job cancelled")
+
+// cancellations keeps track of the jobs running on this Krane instance and of the
+// queued jobs which were cancelled before a worker could pick them up
+type cancellations struct {
+	mu        sync.Mutex
+	running   map[string]context.CancelFunc
+	cancelled map[string]bool
+}
+
+var registry = &cancellations{
+	running:   make(map[string]context.CancelFunc),
+	cancelled: make(map[string]bool),
+}
+
+// begin returns the context a job runs with, false if the job was cancelled while queued
+func (c *cancellations) begin(id string) (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.consumeLocked(id) {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.running[id] = cancel
+	return ctx, true
+}
+
+// consume returns whether a queued job was cancelled, the cancellation is only reported once
+func (c *cancellations) consume(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.consumeLocked(id)
+}
+
+func (c *cancellations) consumeLocked(id string) bool {
+	if c.cancelled[id] {
+		delete(c.cancelled, id)
+		return true
+	}
+	return false
+}
+
+// end releases the context of a job once it's done running
+func (c *cancellations) end(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cancel, ok := c.running[id]; ok {
+		cancel()
+		delete(c.running, id)
+	}
+}
+
+// cancel signals a running job, jobs that are not running yet are flagged so they are skipped once
+// picked up by a worker. Returns true if the job was running and has been signalled.
+func (c *cancellations) cancel(id string, flagQueued bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cancel, ok := c.running[id]; ok {
+		cancel()
+		return true
+	}
+
+	if flagQueued {
+		c.cancelled[id] = true
+	}
+	return false
+}
+
+// Cancel cancels a job. Queued jobs are recorded as cancelled right away and skipped once picked up by a worker.
+// Running jobs are signalled through their context and recorded as cancelled once their handlers return.
+func Cancel(j Job) (Job, error) {
+	switch j.State {
+	case Queued:
+		if registry.cancel(j.ID, true) {
+			// the job started running in the meantime
+			return j, nil
+		}
+
+		j.WithError(ErrCancelled)
+		j.EndTime = time.Now().Unix()
+		j.State = Cancelled
+		j.save()
+		return j, nil
+	case Running:
+		if !registry.cancel(j.ID, false) {
+			return j, fmt.Errorf("job %s is not running", j.ID)
+		}
+		return j, nil
+	default:
+		return j, fmt.Errorf("unable to cancel job %s, job is %s", j.ID, j.State)
+	}
+}
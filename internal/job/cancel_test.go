@@ -0,0 +1,105 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/store"
+)
+
+func TestCancelQueuedJob(t *testing.T) {
+	var ran bool
+	j := Job{
+		ID:          "cancel-queued",
+		Deployment:  namespace,
+		RetryPolicy: 1,
+		Run: func(ctx context.Context, args interface{}) error {
+			ran = true
+			return nil
+		},
+	}
+	j.queued()
+
+	cancelled, err := Cancel(j)
+	assert.Nil(t, err)
+	assert.Equal(t, Cancelled, cancelled.State)
+	assert.Equal(t, Cancelled, storedJob(t, j.ID).State)
+	assert.NotContains(t, queuedJobIDs(t), j.ID)
+
+	// once picked up by a worker the cancelled job is skipped
+	w := newWorker(nil, nil)
+	w.process(j)
+	assert.False(t, ran)
+	assert.Equal(t, Cancelled, storedJob(t, j.ID).State)
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	started := make(chan bool)
+	var rolledBack bool
+	j := Job{
+		ID:          "cancel-running",
+		Deployment:  namespace,
+		RetryPolicy: 3,
+		Run: func(ctx context.Context, args interface{}) error {
+			started <- true
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Rollback: func(ctx context.Context, args interface{}) error {
+			rolledBack = ctx.Err() == nil
+			return nil
+		},
+	}
+	j.queued()
+
+	done := make(chan bool)
+	go func() {
+		w := newWorker(nil, nil)
+		w.process(j)
+		done <- true
+	}()
+
+	<-started
+	_, err := Cancel(storedJob(t, j.ID))
+	assert.Nil(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not cancelled")
+	}
+
+	stored := storedJob(t, j.ID)
+	assert.Equal(t, Cancelled, stored.State)
+	assert.Equal(t, uint(1), stored.Status.ExecutionCount)
+	assert.True(t, rolledBack)
+}
+
+func TestCancelFinishedJob(t *testing.T) {
+	j := Job{ID: "cancel-finished", Deployment: namespace, State: Succeeded}
+	_, err := Cancel(j)
+	assert.Error(t, err)
+
+	j = Job{ID: "cancel-not-running", Deployment: namespace, State: Running}
+	_, err = Cancel(j)
+	assert.Error(t, err)
+}
+
+func storedJob(t *testing.T, id string) Job {
+	bytes, err := store.Client().GetAll(GetJobsCollectionName(namespace))
+	assert.Nil(t, err)
+
+	for _, b := range bytes {
+		var j Job
+		assert.Nil(t, store.Deserialize(b, &j))
+		if j.ID == id {
+			return j
+		}
+	}
+
+	t.Fatalf("job %s not found", id)
+	return Job{}
+}
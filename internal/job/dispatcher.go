@@ -0,0 +1,58 @@
+package job
+
+// dispatcher decides which queued job runs next. At most one job per deployment runs at a time,
+// jobs for a deployment with a running job are held back until the running job is done.
+type dispatcher struct {
+	pending []Job           // jobs waiting to run in the order they were queued
+	active  map[string]bool // deployments with a running job
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		pending: make([]Job, 0),
+		active:  make(map[string]bool),
+	}
+}
+
+// add holds a job until it can run. Jobs following a pending job of the same type for the same deployment
+// are redundant with it, the pending job is replaced by the newer one and returned as superseded.
+func (d *dispatcher) add(j Job) (Job, bool) {
+	for i := len(d.pending) - 1; i >= 0; i-- {
+		if d.pending[i].Deployment != j.Deployment {
+			continue
+		}
+
+		// only the last pending job of the deployment can be superseded
+		// to preserve the order of jobs of a different type
+		if d.pending[i].Type != j.Type {
+			break
+		}
+
+		superseded := d.pending[i]
+		d.pending[i] = j
+		return superseded, true
+	}
+
+	d.pending = append(d.pending, j)
+	return Job{}, false
+}
+
+// next returns the oldest pending job for a deployment without a running job, the deployment is marked as active
+func (d *dispatcher) next() (Job, bool) {
+	for i, j := range d.pending {
+		if d.active[j.Deployment] {
+			continue
+		}
+
+		d.pending = append(d.pending[:i], d.pending[i+1:]...)
+		d.active[j.Deployment] = true
+		return j, true
+	}
+
+	return Job{}, false
+}
+
+// done releases a deployment once its running job is done
+func (d *dispatcher) done(j Job) {
+	delete(d.active, j.Deployment)
+}
@@ -0,0 +1,142 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchOneJobPerDeployment(t *testing.T) {
+	d := newDispatcher()
+	d.add(Job{ID: "1", Deployment: "api", Type: "RUN"})
+	d.add(Job{ID: "2", Deployment: "api", Type: "STOP"})
+	d.add(Job{ID: "3", Deployment: "web", Type: "RUN"})
+
+	j, ok := d.next()
+	assert.True(t, ok)
+	assert.Equal(t, "1", j.ID)
+
+	// the api deployment has a running job so the web job runs next
+	j, ok = d.next()
+	assert.True(t, ok)
+	assert.Equal(t, "3", j.ID)
+
+	_, ok = d.next()
+	assert.False(t, ok)
+
+	d.done(Job{ID: "1", Deployment: "api"})
+	j, ok = d.next()
+	assert.True(t, ok)
+	assert.Equal(t, "2", j.ID)
+}
+
+func TestDispatcherSupersedesRedundantJobs(t *testing.T) {
+	d := newDispatcher()
+
+	_, ok := d.add(Job{ID: "1", Deployment: "api", Type: "RUN"})
+	assert.False(t, ok)
+
+	superseded, ok := d.add(Job{ID: "2", Deployment: "api", Type: "RUN"})
+	assert.True(t, ok)
+	assert.Equal(t, "1", superseded.ID)
+
+	superseded, ok = d.add(Job{ID: "3", Deployment: "api", Type: "RUN"})
+	assert.True(t, ok)
+	assert.Equal(t, "2", superseded.ID)
+
+	// a job of a different type in between is never skipped over
+	d.add(Job{ID: "4", Deployment: "api", Type: "STOP"})
+	_, ok = d.add(Job{ID: "5", Deployment: "api", Type: "RUN"})
+	assert.False(t, ok)
+
+	ids := make([]string, 0)
+	for _, j := range d.pending {
+		ids = append(ids, j.ID)
+	}
+	assert.Equal(t, []string{"3", "4", "5"}, ids)
+}
+
+func TestWorkerPoolSerializesDeploymentJobs(t *testing.T) {
+	queue := make(chan Job, 10)
+	wp := NewWorkerPool(3, queue, nil)
+	wp.Start()
+	defer wp.Stop()
+
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+
+	var wg sync.WaitGroup
+	for i, typ := range []string{"RUN", "STOP", "RUN", "STOP"} {
+		wg.Add(1)
+		queue <- Job{
+			ID:          "serialize-" + string(rune('a'+i)),
+			Deployment:  namespace,
+			Type:        typ,
+			RetryPolicy: 1,
+			Run: func(ctx context.Context, args interface{}) error {
+				defer wg.Done()
+
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			},
+		}
+	}
+
+	wg.Wait()
+	assert.Equal(t, 1, maxRunning)
+}
+
+func TestWorkerPoolStopWaitsForJobsInProgress(t *testing.T) {
+	queue := make(chan Job, 1)
+	wp := NewWorkerPool(1, queue, nil)
+	wp.Start()
+
+	started := make(chan bool)
+	release := make(chan bool)
+	queue <- Job{
+		ID:          "stop-in-progress",
+		Deployment:  namespace,
+		RetryPolicy: 1,
+		Run: func(ctx context.Context, args interface{}) error {
+			started <- true
+			<-release
+			return nil
+		},
+	}
+	<-started
+
+	stopped := make(chan bool)
+	go func() {
+		wp.Stop()
+		stopped <- true
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("worker pool stopped before the job in progress completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the worker completes its job and quits without reporting it to the stopped dispatcher
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("worker pool did not stop once the job in progress completed")
+	}
+}
@@ -18,7 +18,25 @@ func (e *Enqueuer) Enqueue(job Job) (Job, error) {
 	}
 
 	logger.Debugf("Queueing new job %s", job.ID)
+	job.queued()
 	e.queue <- job // Blocks here until space opens up in the queue
 	logger.Debugf("Job %s Queued", job.ID)
 	return job, nil
 }
+
+// EnqueueAsync persists a job as queued without blocking until space opens up in the queue.
+// Once persisted the job is resumed on startup if Krane restarts before the job is picked up.
+func (e *Enqueuer) EnqueueAsync(job Job) (Job, error) {
+	err := job.validate()
+	if err != nil {
+		return Job{}, err
+	}
+
+	logger.Debugf("Queueing new job %s", job.ID)
+	job.queued()
+	go func() {
+		e.queue <- job
+		logger.Debugf("Job %s Queued", job.ID)
+	}()
+	return job, nil
+}
@@ -1,7 +1,9 @@
 package job
 
 import (
+	"context"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -46,11 +48,11 @@ func TestEnqueueNewJobs(t *testing.T) {
 	go func(handler *int) {
 		for i := 0; i < jobCount; i++ {
 			job := Job{
-				ID:         string(i),
+				ID:         strconv.Itoa(i),
 				Deployment: namespace,
 				Type:       "test",
 				Args:       map[string]string{"name": "test"},
-				Run: func(args interface{}) error {
+				Run: func(ctx context.Context, args interface{}) error {
 					assert.Equal(t, "test", args.(map[string]string)["name"])
 					*handler += 1
 					return nil
@@ -66,9 +68,9 @@ func TestEnqueueNewJobs(t *testing.T) {
 	// Assert
 	for i := 0; i < jobCount; i++ {
 		j := <-jobQueue
-		j.Run(j.Args)
+		j.Run(context.Background(), j.Args)
 		assert.NotNil(t, j)
-		assert.Equal(t, j.ID, string(i))
+		assert.Equal(t, j.ID, strconv.Itoa(i))
 		assert.Equal(t, j.Deployment, namespace)
 		assert.Equal(t, j.Args.(map[string]string)["name"], "test")
 	}
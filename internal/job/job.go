@@ -2,7 +2,9 @@ package job
 
 import (
 	"container/heap"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -18,56 +20,179 @@ type Job struct {
 	Deployment  string         `json:"deployment"`       // Deployment used for scoping jobs.
 	Type        string         `json:"type"`             // The type of job
 	Status      Status         `json:"status"`           // The response of the current job with details for execution counts etc..
-	State       State          `json:"state"`            // Current state of a job (running | complete)
+	State       State          `json:"state"`            // Current state of a job (queued | running | succeeded | failed | rolled_back | cancelled)
+	QueueTime   int64          `json:"queue_time_epoch"` // Job queue time - epoch in seconds since 1970
 	StartTime   int64          `json:"start_time_epoch"` // Job Start time - epoch in seconds since 1970
 	EndTime     int64          `json:"end_time_epoch"`   // Job end time - epoch in seconds since 1970
-	RetryPolicy uint           `json:"retry_policy"`     // Job retry policy
+	RetryPolicy uint           `json:"retry_policy"`     // Max attempts at executing a job, a job is only retried when it fails
+	Timeline    []PhaseRecord  `json:"timeline"`         // Phases the job went through with their durations
+	Timeout     time.Duration  `json:"-"`                // Execution timeout for a single attempt, defaults to the timeout for the job type
 	Args        interface{}    `json:"-"`                // Arguments passed down to job handlers
 	Setup       GenericHandler `json:"-"`                // Setup is the initial execution fn for a job typically to setup arguments
 	Run         GenericHandler `json:"-"`                // Run is the main executor fn for a job
 	Finally     GenericHandler `json:"-"`                // Final fn is the final execution fn for a job
+	Rollback    GenericHandler `json:"-"`                // Rollback is the fn used to undo the changes of a failed run
 }
 
-// GenericHandler is a generic job handler that takes in job arguments
-type GenericHandler func(args interface{}) error
+// GenericHandler is a generic job handler that takes in job arguments,
+// the context is cancelled when the job is cancelled while running
+type GenericHandler func(ctx context.Context, args interface{}) error
 
 // Serialize a job into bytes
 func (j *Job) Serialize() ([]byte, error) { return json.Marshal(j) }
 
+// queued marks a job as queued, the job is persisted so it can be resumed if Krane restarts before it runs
+func (j *Job) queued() {
+	if j.QueueTime == 0 {
+		j.QueueTime = time.Now().Unix()
+	}
+
+	j.State = Queued
+	j.save()
+}
+
 // Start : Start a job
 func (j *Job) start() {
-	if j.State == Started {
+	if j.State == Running {
 		return
 	}
 	j.StartTime = time.Now().Unix()
-	j.State = Started
+	j.State = Running
 	j.Status.Failures = []Error{}
+	j.Status.Attempts = []Attempt{}
+	j.Timeline = []PhaseRecord{}
+	j.save()
 }
 
-func (j *Job) end() {
-	if j.State != Started {
+func (j *Job) end() { j.finish(Succeeded) }
+
+// finish ends a job with a final state
+func (j *Job) finish(state State) {
+	if j.State != Running {
 		return
 	}
 	j.EndTime = time.Now().Unix()
-	j.State = Completed
+	j.State = state
 	j.save()
 }
 
-// save : store the job
+// fail ends a job which never got to finish (ie. interrupted by a restart) as failed
+func (j *Job) fail(err error) {
+	j.WithError(err)
+	j.EndTime = time.Now().Unix()
+	j.State = Failed
+	j.save()
+}
+
+// supersede ends a queued job replaced by a newer job before it got to run
+func (j *Job) supersede(by Job) {
+	logger.Debugf("Job %s superseded by job %s", j.ID, by.ID)
+	j.EndTime = time.Now().Unix()
+	j.State = Superseded
+	j.save()
+}
+
+// attempt executes the setup, run and finally fns of a job within the job timeout. The attempt is recorded
+// in the job status, returns whether it was the run fn that failed and the error (if any).
+func (j *Job) attempt(ctx context.Context, attempt uint) (runFailed bool, err error) {
+	j.Status.ExecutionCount++
+	record := Attempt{Attempt: attempt, StartTime: time.Now().Unix()}
+	defer func() {
+		record.EndTime = time.Now().Unix()
+		if err != nil {
+			record.Error = err.Error()
+		}
+		j.Status.Attempts = append(j.Status.Attempts, record)
+	}()
+
+	ctx = withTimeline(ctx, j, attempt)
+
+	timeout := j.timeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// errors caused by the attempt running out of time are reported as a timeout
+	withTimeout := func(err error) error {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("job timed out after %s, %v", timeout, err)
+		}
+		return err
+	}
+
+	if j.Setup != nil {
+		logger.Debugf("Setting up job %s", j.ID)
+		if err := j.Setup(ctx, j.Args); err != nil {
+			return false, withTimeout(err)
+		}
+	}
+
+	if j.Run == nil {
+		return false, errors.New("job must have a Run implementation")
+	}
+
+	if err := j.Run(ctx, j.Args); err != nil {
+		return true, withTimeout(err)
+	}
+
+	if j.Finally != nil {
+		logger.Debugf("Tearing down job %s", j.ID)
+		if err := j.Finally(ctx, j.Args); err != nil {
+			return false, withTimeout(err)
+		}
+	}
+
+	return false, nil
+}
+
+// rollback executes the rollback fn (if any) after a failed run, returns true if the job was rolled back
+func (j *Job) rollback() bool {
+	if j.Rollback == nil {
+		return false
+	}
+
+	// rollbacks always run to completion, even for cancelled jobs, and are
+	// recorded in the timeline as part of the attempt which failed
+	logger.Debugf("Rolling back job %s", j.ID)
+	ctx := withTimeline(context.Background(), j, uint(len(j.Status.Attempts)))
+	if err := j.Rollback(ctx, j.Args); err != nil {
+		j.WithError(err)
+		return false
+	}
+
+	return true
+}
+
+// save : store the job, jobs which are not done are also stored in the job queue collection
 func (j *Job) save() {
 	collection := GetJobsCollectionName(j.Deployment)
 	bytes, _ := j.Serialize()
 
-	// timestamp(RFC3339) is used as the key for the activity.
-	// This leverages bolts time range scans which is an efficient way of performing lookups
-	// for activity within a time range in an efficient manner.
-	timestamp := utils.UTCDateString()
-
-	err := store.Client().Put(collection, timestamp, bytes)
+	key := j.key()
+	err := store.Client().Put(collection, key, bytes)
 	if err != nil {
 		logger.Errorf("Unhandled error when inserting job into the db, %s", err)
 		return
 	}
+
+	if j.State.done() {
+		err = store.Client().Remove(constants.JobQueueCollectionName, key)
+	} else {
+		err = store.Client().Put(constants.JobQueueCollectionName, key, bytes)
+	}
+
+	if err != nil {
+		logger.Errorf("Unhandled error when updating the job queue in the db, %s", err)
+	}
+}
+
+// key returns the key a job is stored under. The queue timestamp(RFC3339) is used as the key for the activity.
+// This leverages bolts time range scans which is an efficient way of performing lookups
+// for activity within a time range in an efficient manner. The job id is appended to keep keys unique.
+func (j *Job) key() string {
+	return fmt.Sprintf("%s_%s", time.Unix(j.QueueTime, 0).Format(time.RFC3339), j.ID)
 }
 
 // validate returns an error if a Job does not have a valid configuration
@@ -1,6 +1,8 @@
 package job
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -10,7 +12,7 @@ import (
 func TestStartJob(t *testing.T) {
 	j := Job{}
 	j.start()
-	assert.Equal(t, Started, j.State)
+	assert.Equal(t, Running, j.State)
 	assert.True(t, time.Now().Unix() >= j.StartTime)
 }
 
@@ -18,11 +20,11 @@ func TestEndJob(t *testing.T) {
 	j := Job{}
 
 	j.start()
-	assert.Equal(t, Started, j.State)
+	assert.Equal(t, Running, j.State)
 	assert.True(t, time.Now().Unix() >= j.StartTime)
 
 	j.end()
-	assert.Equal(t, Completed, j.State)
+	assert.Equal(t, Succeeded, j.State)
 	assert.True(t, time.Now().Unix() >= j.EndTime)
 }
 
@@ -30,13 +32,41 @@ func TestJobNotStartedOnCallToJobEnd(t *testing.T) {
 	j := Job{}
 
 	j.end()
-	assert.NotEqual(t, Completed, j.State)
+	assert.NotEqual(t, Succeeded, j.State)
 
 	j.start()
-	assert.Equal(t, Started, j.State)
+	assert.Equal(t, Running, j.State)
 	assert.True(t, time.Now().Unix() >= j.StartTime)
 
 	j.end()
-	assert.Equal(t, Completed, j.State)
+	assert.Equal(t, Succeeded, j.State)
 	assert.True(t, time.Now().Unix() >= j.EndTime)
 }
+
+func TestRollbackJob(t *testing.T) {
+	j := Job{Deployment: "test"}
+	assert.False(t, j.rollback())
+
+	var rolledBack bool
+	j.Rollback = func(ctx context.Context, args interface{}) error {
+		rolledBack = true
+		return nil
+	}
+	assert.True(t, j.rollback())
+	assert.True(t, rolledBack)
+
+	j.start()
+	j.finish(RolledBack)
+	assert.Equal(t, RolledBack, j.State)
+	assert.True(t, time.Now().Unix() >= j.EndTime)
+}
+
+func TestFailedRollbackRecordsError(t *testing.T) {
+	j := Job{Deployment: "test"}
+	j.Rollback = func(ctx context.Context, args interface{}) error {
+		return errors.New("unable to remove container")
+	}
+
+	assert.False(t, j.rollback())
+	assert.Equal(t, "unable to remove container", j.Status.Failures[0].Message)
+}
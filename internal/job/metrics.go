@@ -0,0 +1,45 @@
+package job
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// pendingJobs is the amount of jobs held by the dispatcher until a worker is ready or the
+// running job of their deployment is done, set by the dispatcher after every change
+var pendingJobs int64
+
+var (
+	queueDepth = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "krane",
+		Name:      "job_queue_depth",
+		Help:      "Jobs waiting to run, either in the job queue or held by the dispatcher.",
+	}, func() float64 { return float64(len(Queue())) + float64(atomic.LoadInt64(&pendingJobs)) })
+
+	workersTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "krane",
+		Name:      "job_workers",
+		Help:      "Workers started in the worker pool.",
+	})
+
+	workersBusy = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "krane",
+		Name:      "job_workers_busy",
+		Help:      "Workers currently executing a job.",
+	})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "krane",
+		Name:      "job_duration_seconds",
+		Help:      "Execution time of jobs including retries, by job type and final state.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"type", "state"})
+
+	jobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "krane",
+		Name:      "jobs_total",
+		Help:      "Jobs executed by job type and final state.",
+	}, []string{"type", "state"})
+)
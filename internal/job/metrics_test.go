@@ -0,0 +1,61 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessedJobsAreCountedByTypeAndState(t *testing.T) {
+	run := func(id string, err error) {
+		j := Job{
+			ID:          id,
+			Deployment:  namespace,
+			Type:        "METRICS_TEST",
+			RetryPolicy: 1,
+			Run:         func(ctx context.Context, args interface{}) error { return err },
+		}
+		j.queued()
+		newWorker(nil, nil).process(j)
+	}
+
+	run("metrics-succeeded", nil)
+	run("metrics-failed", errors.New("unable to pull image"))
+	run("metrics-failed-again", errors.New("unable to pull image"))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(jobsTotal.WithLabelValues("METRICS_TEST", string(Succeeded))))
+	assert.Equal(t, float64(2), testutil.ToFloat64(jobsTotal.WithLabelValues("METRICS_TEST", string(Failed))))
+	assert.Equal(t, float64(0), testutil.ToFloat64(workersBusy))
+}
+
+func TestJobsHeldByTheDispatcherAreCountedInTheQueueDepth(t *testing.T) {
+	wp := WorkerPool{
+		workerPool: make(chan chan Job, 1),
+		jobChannel: make(chan Job),
+		finished:   make(chan Job),
+		quit:       make(chan struct{}),
+	}
+	go wp.dispatch()
+	defer close(wp.quit)
+
+	worker := make(chan Job, 1)
+	wp.workerPool <- worker
+
+	running := Job{ID: "metrics-running", Deployment: namespace, Type: "METRICS_TEST"}
+	wp.jobChannel <- running
+	assert.Equal(t, running.ID, (<-worker).ID)
+
+	// a job for the same deployment waits for the running job
+	held := Job{ID: "metrics-held", Deployment: namespace, Type: "METRICS_HELD_TEST"}
+	wp.jobChannel <- held
+	wp.workerPool <- worker
+	assert.Eventually(t, func() bool { return testutil.ToFloat64(queueDepth) == 1 }, time.Second, 10*time.Millisecond)
+
+	wp.finished <- running
+	assert.Equal(t, held.ID, (<-worker).ID)
+	assert.Eventually(t, func() bool { return testutil.ToFloat64(queueDepth) == 0 }, time.Second, 10*time.Millisecond)
+}
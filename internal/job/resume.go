@@ -0,0 +1,68 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// Builder re-creates the handlers and arguments for a persisted job so it can be resumed
+type Builder func(j Job) (Job, error)
+
+// ResumeInterrupted recovers the jobs left in the job queue when Krane stopped. Queued jobs are rebuilt and
+// queued again in the order they were originally queued. Jobs that were running are marked as failed
+// since they can't be safely resumed from where they were interrupted.
+func ResumeInterrupted(queue chan Job, build Builder) {
+	jobs, err := GetQueuedJobs()
+	if err != nil {
+		logger.Errorf("unable to get queued jobs %v", err)
+		return
+	}
+
+	logger.Debugf("Resuming %d interrupted job(s)", len(jobs))
+	for _, j := range jobs {
+		if j.State != Queued {
+			logger.Warnf("Job %s for deployment %s was interrupted while %s", j.ID, j.Deployment, j.State)
+			j.fail(errors.New("job interrupted by a Krane restart"))
+			continue
+		}
+
+		resumed, err := build(j)
+		if err != nil {
+			logger.Warnf("unable to resume job %s %v", j.ID, err)
+			j.fail(fmt.Errorf("unable to resume job, %v", err))
+			continue
+		}
+
+		// keep the original identity so the job record is updated rather than duplicated
+		resumed.ID = j.ID
+		resumed.Deployment = j.Deployment
+		resumed.QueueTime = j.QueueTime
+		resumed.State = Queued
+
+		logger.Debugf("Resuming job %s for deployment %s", j.ID, j.Deployment)
+		queue <- resumed
+	}
+}
+
+// GetQueuedJobs returns the jobs which are queued or running, ordered by when they were queued
+func GetQueuedJobs() ([]Job, error) {
+	bytes, err := store.Client().GetAll(constants.JobQueueCollectionName)
+	if err != nil {
+		return make([]Job, 0), err
+	}
+
+	jobs := make([]Job, 0)
+	for _, b := range bytes {
+		var j Job
+		if err := store.Deserialize(b, &j); err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,82 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestJobQueueTracksUnfinishedJobs(t *testing.T) {
+	j := Job{ID: "queue-test", Deployment: namespace, Type: "test"}
+	j.queued()
+	assert.Equal(t, Queued, j.State)
+	assert.NotZero(t, j.QueueTime)
+	assert.Contains(t, queuedJobIDs(t), j.ID)
+
+	j.start()
+	assert.Contains(t, queuedJobIDs(t), j.ID)
+
+	j.end()
+	assert.Equal(t, Succeeded, j.State)
+	assert.NotContains(t, queuedJobIDs(t), j.ID)
+}
+
+func TestResumeInterruptedJobs(t *testing.T) {
+	// start from an empty queue, other tests leave jobs queued
+	_ = store.Client().DeleteCollection(constants.JobQueueCollectionName)
+
+	queued := Job{ID: "resume-queued", Deployment: namespace, Type: "test"}
+	queued.queued()
+
+	running := Job{ID: "resume-running", Deployment: namespace, Type: "test"}
+	running.queued()
+	running.start()
+
+	unknown := Job{ID: "resume-unknown", Deployment: namespace, Type: "unknown"}
+	unknown.queued()
+
+	build := func(j Job) (Job, error) {
+		if j.Type != "test" {
+			return Job{}, errors.New("unknown job type")
+		}
+		return Job{Type: j.Type, Run: func(ctx context.Context, args interface{}) error { return nil }}, nil
+	}
+
+	queue := make(chan Job, 3)
+	ResumeInterrupted(queue, build)
+	close(queue)
+
+	resumed := make([]Job, 0)
+	for j := range queue {
+		resumed = append(resumed, j)
+	}
+
+	assert.Len(t, resumed, 1)
+	assert.Equal(t, queued.ID, resumed[0].ID)
+	assert.Equal(t, namespace, resumed[0].Deployment)
+	assert.Equal(t, queued.QueueTime, resumed[0].QueueTime)
+	assert.Equal(t, Queued, resumed[0].State)
+	assert.NotNil(t, resumed[0].Run)
+
+	// jobs which can't be resumed are no longer queued
+	ids := queuedJobIDs(t)
+	assert.Contains(t, ids, queued.ID)
+	assert.NotContains(t, ids, running.ID)
+	assert.NotContains(t, ids, unknown.ID)
+}
+
+func queuedJobIDs(t *testing.T) []string {
+	jobs, err := GetQueuedJobs()
+	assert.Nil(t, err)
+
+	ids := make([]string, 0)
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+	return ids
+}
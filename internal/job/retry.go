@@ -0,0 +1,74 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/utils"
+)
+
+// retryBackoff returns the delay before retrying a job after a number of failed attempts. The delay
+// starts at the configured backoff and doubles after every failed attempt up to the max backoff.
+func retryBackoff(failedAttempts int) time.Duration {
+	base := utils.DurationEnvMs(constants.EnvJobRetryBackoffMs, 0)
+	max := utils.DurationEnvMs(constants.EnvJobRetryMaxBackoffMs, 0)
+	return exponentialBackoff(base, max, failedAttempts)
+}
+
+// exponentialBackoff returns base * 2^(failedAttempts-1) bound by max, max is ignored when 0
+func exponentialBackoff(base, max time.Duration, failedAttempts int) time.Duration {
+	if base <= 0 || failedAttempts < 1 {
+		return 0
+	}
+
+	backoff := base
+	for i := 1; i < failedAttempts; i++ {
+		backoff *= 2
+		if max > 0 && backoff >= max {
+			return max
+		}
+	}
+
+	if max > 0 && backoff > max {
+		return max
+	}
+	return backoff
+}
+
+// timeout returns the execution timeout for a single attempt of a job. Jobs without their own timeout use
+// the timeout for their type (JOB_TIMEOUT_<TYPE>_MS) or the default job timeout, 0 means no timeout.
+func (j *Job) timeout() time.Duration {
+	if j.Timeout > 0 {
+		return j.Timeout
+	}
+
+	if j.Type != "" {
+		if timeout := utils.DurationEnvMs(typeTimeoutEnv(j.Type), 0); timeout > 0 {
+			return timeout
+		}
+	}
+
+	return utils.DurationEnvMs(constants.EnvJobTimeoutMs, 0)
+}
+
+// typeTimeoutEnv returns the environment variable used to configure the timeout for a job type
+func typeTimeoutEnv(jobType string) string {
+	return fmt.Sprintf("JOB_TIMEOUT_%s_MS", strings.ToUpper(jobType))
+}
+
+// wait pauses for a duration, returns early with an error if the context is done
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := time.Second
+
+	assert.Equal(t, time.Duration(0), exponentialBackoff(0, max, 3))
+	assert.Equal(t, 100*time.Millisecond, exponentialBackoff(base, max, 1))
+	assert.Equal(t, 200*time.Millisecond, exponentialBackoff(base, max, 2))
+	assert.Equal(t, 800*time.Millisecond, exponentialBackoff(base, max, 4))
+	assert.Equal(t, time.Second, exponentialBackoff(base, max, 5))
+	assert.Equal(t, time.Second, exponentialBackoff(base, max, 50))
+	assert.Equal(t, 1600*time.Millisecond, exponentialBackoff(base, 0, 5))
+}
+
+func TestJobTimeout(t *testing.T) {
+	defer os.Unsetenv("JOB_TIMEOUT_MS")
+	defer os.Unsetenv("JOB_TIMEOUT_RUN_DEPLOYMENT_MS")
+
+	j := Job{Type: "RUN_DEPLOYMENT"}
+	assert.Equal(t, time.Duration(0), j.timeout())
+
+	os.Setenv("JOB_TIMEOUT_MS", "60000")
+	assert.Equal(t, time.Minute, j.timeout())
+
+	os.Setenv("JOB_TIMEOUT_RUN_DEPLOYMENT_MS", "120000")
+	assert.Equal(t, 2*time.Minute, j.timeout())
+	assert.Equal(t, time.Minute, (&Job{Type: "STOP_CONTAINERS"}).timeout())
+
+	j.Timeout = time.Second
+	assert.Equal(t, time.Second, j.timeout())
+}
+
+func TestSucceededJobIsNotRetried(t *testing.T) {
+	runs := 0
+	j := Job{
+		ID:          "retry-success",
+		Deployment:  namespace,
+		RetryPolicy: 3,
+		Run: func(ctx context.Context, args interface{}) error {
+			runs++
+			return nil
+		},
+	}
+	j.queued()
+
+	newWorker(nil, nil).process(j)
+
+	stored := storedJob(t, j.ID)
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, Succeeded, stored.State)
+	assert.Len(t, stored.Status.Attempts, 1)
+	assert.Empty(t, stored.Status.Attempts[0].Error)
+}
+
+func TestFailedJobIsRetried(t *testing.T) {
+	runs := 0
+	rollbacks := 0
+	j := Job{
+		ID:          "retry-failure",
+		Deployment:  namespace,
+		RetryPolicy: 3,
+		Run: func(ctx context.Context, args interface{}) error {
+			runs++
+			if runs < 3 {
+				return errors.New("unable to pull image")
+			}
+			return nil
+		},
+		Rollback: func(ctx context.Context, args interface{}) error {
+			rollbacks++
+			return nil
+		},
+	}
+	j.queued()
+
+	newWorker(nil, nil).process(j)
+
+	// a job rolled back after a failed attempt which then succeeds is not rolled back
+	stored := storedJob(t, j.ID)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 2, rollbacks)
+	assert.Equal(t, Succeeded, stored.State)
+	assert.Equal(t, uint(3), stored.Status.ExecutionCount)
+	assert.Equal(t, uint(2), stored.Status.FailureCount)
+	assert.Len(t, stored.Status.Attempts, 3)
+	assert.Equal(t, uint(1), stored.Status.Attempts[0].Attempt)
+	assert.Equal(t, "unable to pull image", stored.Status.Attempts[0].Error)
+	assert.Equal(t, "unable to pull image", stored.Status.Attempts[1].Error)
+	assert.Empty(t, stored.Status.Attempts[2].Error)
+}
+
+func TestTimedOutJobFails(t *testing.T) {
+	j := Job{
+		ID:          "retry-timeout",
+		Deployment:  namespace,
+		RetryPolicy: 2,
+		Timeout:     20 * time.Millisecond,
+		Run: func(ctx context.Context, args interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	j.queued()
+
+	newWorker(nil, nil).process(j)
+
+	stored := storedJob(t, j.ID)
+	assert.Equal(t, Failed, stored.State)
+	assert.Len(t, stored.Status.Attempts, 2)
+	assert.Contains(t, stored.Status.Attempts[1].Error, "job timed out after 20ms")
+}
@@ -3,6 +3,16 @@ package job
 type State string
 
 const (
-	Started   State = "STARTED"
-	Completed State = "COMPLETED"
+	Queued     State = "QUEUED"
+	Running    State = "RUNNING"
+	Succeeded  State = "SUCCEEDED"
+	Failed     State = "FAILED"
+	RolledBack State = "ROLLED_BACK"
+	Cancelled  State = "CANCELLED"
+	Superseded State = "SUPERSEDED"
 )
+
+// done returns whether a state is final, jobs in a final state are no longer part of the queue
+func (s State) done() bool {
+	return s == Succeeded || s == Failed || s == RolledBack || s == Cancelled || s == Superseded
+}
@@ -1,7 +1,16 @@
 package job
 
 type Status struct {
-	ExecutionCount uint    `json:"execution_count"`
-	FailureCount   uint    `json:"failure_count"`
-	Failures       []Error `json:"failures"`
+	ExecutionCount uint      `json:"execution_count"`
+	FailureCount   uint      `json:"failure_count"`
+	Failures       []Error   `json:"failures"`
+	Attempts       []Attempt `json:"attempts"`
+}
+
+// Attempt is the record of a single execution of a job
+type Attempt struct {
+	Attempt   uint   `json:"attempt"`
+	StartTime int64  `json:"start_time_epoch"`
+	EndTime   int64  `json:"end_time_epoch"`
+	Error     string `json:"error,omitempty"`
 }
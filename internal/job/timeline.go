@@ -0,0 +1,58 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// PhaseRecord is the record of a single phase (step) of a job, phases are named by the job handlers
+type PhaseRecord struct {
+	Phase      string `json:"phase"`            // Name of the phase (ie. PULL_IMAGE)
+	Attempt    uint   `json:"attempt"`          // Attempt at executing the job the phase is part of
+	StartTime  int64  `json:"start_time_epoch"` // Phase start time - epoch in seconds since 1970
+	EndTime    int64  `json:"end_time_epoch"`   // Phase end time - epoch in seconds since 1970
+	DurationMs int64  `json:"duration_ms"`      // Time spent in the phase in milliseconds
+	Error      string `json:"error,omitempty"`  // Error which ended the phase (if any)
+}
+
+// timelineKey is the context key of the timeline recorder of the job being executed
+type timelineKey struct{}
+
+// timeline records the phases of a job while its handlers are executing
+type timeline struct {
+	job     *Job
+	attempt uint
+}
+
+// withTimeline returns a context recording phases into the timeline of a job for an attempt
+func withTimeline(ctx context.Context, j *Job, attempt uint) context.Context {
+	return context.WithValue(ctx, timelineKey{}, &timeline{job: j, attempt: attempt})
+}
+
+// StartPhase records the start of a phase in the timeline of the job executing with ctx. The returned fn
+// must be called once the phase ends with the error ending it (if any), the timeline is persisted as phases end.
+// Phases started outside of a job (ie. no timeline in the context) are not recorded.
+func StartPhase(ctx context.Context, phase string) func(err error) {
+	t, ok := ctx.Value(timelineKey{}).(*timeline)
+	if !ok {
+		return func(err error) {}
+	}
+
+	start := time.Now()
+	return func(err error) {
+		end := time.Now()
+		record := PhaseRecord{
+			Phase:      phase,
+			Attempt:    t.attempt,
+			StartTime:  start.Unix(),
+			EndTime:    end.Unix(),
+			DurationMs: end.Sub(start).Milliseconds(),
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		t.job.Timeline = append(t.job.Timeline, record)
+		t.job.save()
+	}
+}
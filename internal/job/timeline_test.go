@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTimelineRecordsPhases(t *testing.T) {
+	runs := 0
+	j := Job{
+		ID:          "timeline",
+		Deployment:  namespace,
+		RetryPolicy: 2,
+		Setup: func(ctx context.Context, args interface{}) error {
+			StartPhase(ctx, "SETUP")(nil)
+			return nil
+		},
+		Run: func(ctx context.Context, args interface{}) error {
+			runs++
+			if runs == 1 {
+				err := errors.New("unable to pull image")
+				StartPhase(ctx, "PULL_IMAGE")(err)
+				return err
+			}
+			StartPhase(ctx, "PULL_IMAGE")(nil)
+			return nil
+		},
+		Rollback: func(ctx context.Context, args interface{}) error {
+			StartPhase(ctx, "ROLLBACK")(nil)
+			return nil
+		},
+	}
+	j.queued()
+
+	newWorker(nil, nil).process(j)
+
+	stored := storedJob(t, j.ID)
+	assert.Equal(t, Succeeded, stored.State)
+	assert.Len(t, stored.Timeline, 5)
+
+	expected := []struct {
+		phase   string
+		attempt uint
+		failed  bool
+	}{
+		{"SETUP", 1, false},
+		{"PULL_IMAGE", 1, true},
+		{"ROLLBACK", 1, false},
+		{"SETUP", 2, false},
+		{"PULL_IMAGE", 2, false},
+	}
+	for i, e := range expected {
+		record := stored.Timeline[i]
+		assert.Equal(t, e.phase, record.Phase)
+		assert.Equal(t, e.attempt, record.Attempt)
+		assert.Equal(t, e.failed, record.Error != "")
+		assert.True(t, record.EndTime >= record.StartTime)
+		assert.True(t, record.DurationMs >= 0)
+	}
+}
+
+func TestStartPhaseOutsideJob(t *testing.T) {
+	// phases started without a job timeline are not recorded and must not panic
+	StartPhase(context.Background(), "SETUP")(errors.New("unable to setup"))
+}
@@ -2,8 +2,8 @@ package job
 
 import (
 	"os"
-
-	"github.com/pkg/errors"
+	"sync"
+	"time"
 
 	"github.com/krane/krane/internal/logger"
 )
@@ -11,76 +11,123 @@ import (
 type worker struct {
 	workerPool chan chan Job
 	channel    chan Job
-	quit       chan bool
+	finished   chan Job
 }
 
 // newWorker is a helper for creating new workers; a worker runs in its
-// own routine waiting to process work from a job queue
-func newWorker(workerPool chan chan Job, jobChannel chan Job) *worker {
-	return &worker{workerPool, jobChannel, make(chan bool)}
+// own routine registering itself to the worker pool when ready to process a job
+func newWorker(workerPool chan chan Job, finished chan Job) *worker {
+	return &worker{workerPool, make(chan Job, 1), finished}
 }
 
-// Start starts a worker
-func (w *worker) start() {
+// start starts a worker running until the worker pool quit channel is closed,
+// the wait group is released once the worker has stopped
+func (w *worker) start(quit <-chan struct{}, wg *sync.WaitGroup) {
 	logger.Debugf("Worker starting with pid: %d", os.Getpid())
-	go w.loop()
-}
-
-// stop stops a worker
-func (w *worker) stop() {
-	logger.Debug("Worker stopping")
-	w.quit <- true
-	return
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.loop(quit)
+	}()
 }
 
-// loop will infinitely block for jobs to come through from job queue
-func (w *worker) loop() {
+// loop will infinitely block for jobs to be dispatched to the worker. A job in progress when
+// the worker pool stops is completed but not reported since the dispatcher is no longer running.
+func (w *worker) loop(quit <-chan struct{}) {
 	logger.Debug("Worker loop started")
 	for {
+		// register the worker as ready to process a job
+		select {
+		case w.workerPool <- w.channel:
+		case <-quit:
+			logger.Debug("Quitting worker")
+			return
+		}
+
 		select {
 		case job := <-w.channel:
-			job.start()
-
-			for i := 0; i < int(job.RetryPolicy); i++ {
-				job.Status.ExecutionCount++
-
-				if job.Setup != nil {
-					logger.Debugf("Setting up job %s", job.ID)
-					if err := job.Setup(job.Args); err != nil {
-						job.WithError(err)
-						job.Status.FailureCount++
-						continue
-					}
-				}
-
-				if job.Run == nil {
-					job.WithError(errors.New("job must have a Run implementation"))
-					job.Status.FailureCount++
-					return
-				}
-
-				if err := job.Run(job.Args); err != nil {
-					job.WithError(err)
-					job.Status.FailureCount++
-					continue
-				}
-
-				if job.Finally != nil {
-					logger.Debugf("Tearing down job %s", job.ID)
-					if err := job.Finally(job.Args); err != nil {
-						job.WithError(err)
-						job.Status.FailureCount++
-						continue
-					}
-				}
-
-				logger.Debugf("Completed job %s", job.ID)
+			w.process(job)
+			select {
+			case w.finished <- job:
+			case <-quit:
+				logger.Debug("Quitting worker")
+				return
 			}
-
-			job.end()
-		case <-w.quit:
+		case <-quit:
 			logger.Debug("Quitting worker")
 			return
 		}
 	}
 }
+
+// process executes a job, failed attempts are retried based on the job retry policy with an exponential
+// backoff between attempts. Jobs cancelled while queued are skipped.
+func (w *worker) process(job Job) {
+	ctx, ok := registry.begin(job.ID)
+	if !ok {
+		logger.Debugf("Skipping cancelled job %s", job.ID)
+		return
+	}
+	defer registry.end(job.ID)
+
+	workersBusy.Inc()
+	defer workersBusy.Dec()
+
+	startTime := time.Now()
+	job.start()
+
+	attempts := int(job.RetryPolicy)
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// whether the last attempt failed and if its changes were rolled back
+	failed := true
+	rolledBack := false
+
+	for i := 1; i <= attempts; i++ {
+		if i > 1 {
+			backoff := retryBackoff(i - 1)
+			logger.Debugf("Retrying job %s in %s, attempt %d/%d", job.ID, backoff, i, attempts)
+			if err := wait(ctx, backoff); err != nil {
+				break
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		runFailed, err := job.attempt(ctx, uint(i))
+		if err == nil {
+			failed = false
+			logger.Debugf("Completed job %s", job.ID)
+			break
+		}
+
+		job.WithError(err)
+		job.Status.FailureCount++
+
+		rolledBack = false
+		if runFailed {
+			rolledBack = job.rollback()
+		}
+		job.save()
+	}
+
+	switch {
+	case failed && ctx.Err() != nil:
+		logger.Debugf("Cancelled job %s", job.ID)
+		job.WithError(ErrCancelled)
+		job.finish(Cancelled)
+	case failed && rolledBack:
+		job.finish(RolledBack)
+	case failed:
+		job.finish(Failed)
+	default:
+		job.end()
+	}
+
+	jobDuration.WithLabelValues(job.Type, string(job.State)).Observe(time.Since(startTime).Seconds())
+	jobsTotal.WithLabelValues(job.Type, string(job.State)).Inc()
+}
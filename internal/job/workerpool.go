@@ -3,6 +3,7 @@ package job
 import (
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/store"
@@ -21,6 +22,11 @@ type WorkerPool struct {
 	workers    []*worker
 	workerPool chan chan Job
 	jobChannel chan Job
+	finished   chan Job
+
+	// quit is closed to stop the dispatcher and the workers
+	quit    chan struct{}
+	running *sync.WaitGroup
 }
 
 // NewWorkerPool : create a concurrent pool of workers to process Jobs from the queue
@@ -33,11 +39,14 @@ func NewWorkerPool(concurrency uint, jobChannel chan Job, store store.Store) Wor
 		store:        store,
 		workerPool:   make(chan chan Job, concurrency),
 		jobChannel:   jobChannel,
+		finished:     make(chan Job),
+		quit:         make(chan struct{}),
+		running:      &sync.WaitGroup{},
 	}
 
 	for i := uint(0); i < wp.concurrency; i++ {
 		logger.Debugf("Appending new worker to worker pool %s", wp.workerPoolID)
-		w := newWorker(wp.workerPool, wp.jobChannel)
+		w := newWorker(wp.workerPool, wp.finished)
 		wp.workers = append(wp.workers, w)
 	}
 
@@ -54,13 +63,17 @@ func (wp *WorkerPool) Start() {
 	}
 
 	wp.started = true
+	wp.quit = make(chan struct{})
+
+	go wp.dispatch()
 
 	var workersStarted int
 	for _, w := range wp.workers {
 		logger.Debug("Starting new worker")
-		w.start()
+		w.start(wp.quit, wp.running)
 		workersStarted++
 	}
+	workersTotal.Add(float64(workersStarted))
 
 	logger.Debugf("Started %d worker(s)", workersStarted)
 
@@ -79,20 +92,46 @@ func (wp *WorkerPool) Stop() {
 
 	logger.Debugf("Stopping worker pool %s", wp.workerPoolID)
 
-	stopped := 0
-	var wg sync.WaitGroup
-	for _, w := range wp.workers {
-		logger.Debug("Adding worker to waitgroup")
-		wg.Add(1)
-		go func(w *worker) {
-			logger.Debug("Attempting to stop Worker")
-			w.stop()
-			wg.Done()
-			logger.Debug("Worker stopped, removing from waitgroup")
-		}(w)
-		stopped++
-	}
+	// workers processing a job finish it before stopping
+	close(wp.quit)
+	wp.running.Wait()
+	workersTotal.Sub(float64(len(wp.workers)))
+	logger.Debugf("%d worker(s) stopped", len(wp.workers))
+}
 
-	wg.Wait()
-	logger.Debugf("%d out of %d worker(s) stopped", stopped, len(wp.workers))
+// dispatch hands off queued jobs to ready workers making sure at most one job per deployment
+// runs at a time. Redundant queued jobs are superseded by newer jobs while waiting to run.
+func (wp *WorkerPool) dispatch() {
+	logger.Debugf("Dispatching jobs for worker pool %s", wp.workerPoolID)
+
+	d := newDispatcher()
+	ready := make([]chan Job, 0)
+	for {
+		select {
+		case j := <-wp.jobChannel:
+			if superseded, ok := d.add(j); ok && !registry.consume(superseded.ID) {
+				superseded.supersede(j)
+			}
+		case w := <-wp.workerPool:
+			ready = append(ready, w)
+		case j := <-wp.finished:
+			d.done(j)
+		case <-wp.quit:
+			logger.Debugf("Stopped dispatching jobs for worker pool %s", wp.workerPoolID)
+			atomic.StoreInt64(&pendingJobs, 0)
+			return
+		}
+
+		for len(ready) > 0 {
+			j, ok := d.next()
+			if !ok {
+				break
+			}
+
+			ready[0] <- j
+			ready = ready[1:]
+		}
+
+		atomic.StoreInt64(&pendingJobs, int64(len(d.pending)))
+	}
 }
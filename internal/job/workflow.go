@@ -1,6 +1,8 @@
 package job
 
 import (
+	"context"
+
 	"github.com/krane/krane/internal/logger"
 )
 
@@ -38,7 +40,7 @@ func (wf *Workflow) With(name string, handler GenericHandler) {
 
 // Start : executes every Step in a Workflow
 // returns an error if any Step in the Workflow errors out.
-func (wf *Workflow) Start() error {
+func (wf *Workflow) Start(ctx context.Context) error {
 	wf.curr = wf.head
 
 	// run every Step starting from the head of the Workflow
@@ -47,7 +49,7 @@ func (wf *Workflow) Start() error {
 		logger.Debugf("Running Workflow %s | Step %s", wf.name, wf.curr.name)
 
 		// execute every Step passing down args
-		err := wf.curr.fn(wf.args)
+		err := wf.curr.fn(ctx, wf.args)
 		if err != nil {
 			// if any Step fails, the Workflow
 			// stops executing further steps
@@ -1,6 +1,7 @@
 package job
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -10,7 +11,7 @@ import (
 
 func TestWorkflowWithNoStepsDoesntError(t *testing.T) {
 	wf := NewWorkflow("noSteps", nil)
-	err := wf.Start()
+	err := wf.Start(context.Background())
 	assert.Nil(t, err)
 }
 
@@ -33,7 +34,7 @@ func TestWorkflowWithNSteps(t *testing.T) {
 	x := 0
 
 	// Step function used to increment x
-	incX := func(args interface{}) error {
+	incX := func(ctx context.Context, args interface{}) error {
 		x := args.(map[string]*int)["stepCount"]
 		*x++
 		return nil
@@ -55,7 +56,7 @@ func TestWorkflowWithNSteps(t *testing.T) {
 	}
 
 	// Start the Workflow
-	err := wf.Start()
+	err := wf.Start(context.Background())
 
 	assert.Nil(t, err)
 	assert.Equal(t, stepCount, *args["stepCount"])
@@ -64,7 +65,7 @@ func TestWorkflowWithNSteps(t *testing.T) {
 func TestWorkflowError(t *testing.T) {
 	wf := NewWorkflow("testWorkflowError", nil)
 
-	step := func(args interface{}) error {
+	step := func(ctx context.Context, args interface{}) error {
 		if args == nil {
 			return errors.New("Step args cannot be nil")
 		}
@@ -73,7 +74,7 @@ func TestWorkflowError(t *testing.T) {
 
 	wf.With("VerifyArgsNotNil", step)
 
-	err := wf.Start()
+	err := wf.Start(context.Background())
 
 	assert.Error(t, err)
 	assert.Equal(t, "Step args cannot be nil", err.Error())
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCooldownMultiplier caps how many times the base cooldown a deployment can be held back for
+const maxCooldownMultiplier = 16
+
+// cooldown tracks when deployments were last reconciled so a deployment that keeps drifting
+// (ie. a crashing image) is not rescheduled on every poll. The wait time doubles for every
+// consecutive reconcile and resets once the deployment is back in its desired state.
+type cooldown struct {
+	mu      sync.Mutex
+	base    time.Duration
+	entries map[string]cooldownEntry
+}
+
+type cooldownEntry struct {
+	attempts uint
+	until    time.Time
+}
+
+// newCooldown returns a cooldown waiting at least base between reconciles of the same deployment
+func newCooldown(base time.Duration) *cooldown {
+	return &cooldown{base: base, entries: make(map[string]cooldownEntry)}
+}
+
+// active returns true if a deployment was recently reconciled and should not be reconciled again yet
+func (c *cooldown) active(deployment string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[deployment]
+	if !ok {
+		return false
+	}
+
+	return now.Before(entry.until)
+}
+
+// start records a reconcile for a deployment, backing off exponentially on consecutive reconciles
+func (c *cooldown) start(deployment string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entries[deployment]
+
+	multiplier := time.Duration(1) << entry.attempts
+	if multiplier > maxCooldownMultiplier {
+		multiplier = maxCooldownMultiplier
+	} else {
+		entry.attempts++
+	}
+
+	entry.until = now.Add(c.base * multiplier)
+	c.entries[deployment] = entry
+}
+
+// reset clears the cooldown of a deployment once it's back in its desired state
+func (c *cooldown) reset(deployment string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, deployment)
+}
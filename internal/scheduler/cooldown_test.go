@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooldownNotActiveForUnknownDeployment(t *testing.T) {
+	c := newCooldown(time.Minute)
+	assert.False(t, c.active("krane-test", time.Now()))
+}
+
+func TestCooldownActiveAfterStart(t *testing.T) {
+	now := time.Now()
+	c := newCooldown(time.Minute)
+
+	c.start("krane-test", now)
+	assert.True(t, c.active("krane-test", now))
+	assert.True(t, c.active("krane-test", now.Add(59*time.Second)))
+	assert.False(t, c.active("krane-test", now.Add(time.Minute)))
+	assert.False(t, c.active("other-deployment", now))
+}
+
+func TestCooldownBacksOffOnConsecutiveStarts(t *testing.T) {
+	now := time.Now()
+	c := newCooldown(time.Minute)
+
+	c.start("krane-test", now)
+	c.start("krane-test", now)
+	assert.True(t, c.active("krane-test", now.Add(time.Minute)))
+	assert.False(t, c.active("krane-test", now.Add(2*time.Minute)))
+
+	// backoff is capped to the max multiplier
+	for i := 0; i < 10; i++ {
+		c.start("krane-test", now)
+	}
+	assert.True(t, c.active("krane-test", now.Add((maxCooldownMultiplier-1)*time.Minute)))
+	assert.False(t, c.active("krane-test", now.Add(maxCooldownMultiplier*time.Minute)))
+}
+
+func TestCooldownReset(t *testing.T) {
+	now := time.Now()
+	c := newCooldown(time.Minute)
+
+	c.start("krane-test", now)
+	c.start("krane-test", now)
+	c.reset("krane-test")
+	assert.False(t, c.active("krane-test", now))
+
+	// after a reset the backoff starts over
+	c.start("krane-test", now)
+	assert.False(t, c.active("krane-test", now.Add(time.Minute)))
+}
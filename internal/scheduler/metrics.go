@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pollDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "krane",
+		Name:      "scheduler_poll_duration_seconds",
+		Help:      "Time taken by the scheduler to poll deployments and queue reconcile jobs.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	driftTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "krane",
+		Name:      "scheduler_drift_total",
+		Help:      "Polls which found a deployment not in its desired state, by deployment.",
+	}, []string{"deployment"})
+)
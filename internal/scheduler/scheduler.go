@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/krane/krane/internal/deployment"
 	"github.com/krane/krane/internal/docker"
@@ -17,12 +18,14 @@ type Scheduler struct {
 	docker   *docker.Client
 	enqueuer job.Enqueuer
 	interval time.Duration
+	cooldown *cooldown
 }
 
 // New returns a new scheduler used to poll and create deployment resources
-func New(store store.Store, dockerClient *docker.Client, jobEnqueuer job.Enqueuer, interval_ms string) Scheduler {
+func New(store store.Store, dockerClient *docker.Client, jobEnqueuer job.Enqueuer, interval_ms string, cooldown_ms string) Scheduler {
 	interval, _ := time.ParseDuration(interval_ms + "ms")
-	return Scheduler{store, dockerClient, jobEnqueuer, interval}
+	cooldownInterval, _ := time.ParseDuration(cooldown_ms + "ms")
+	return Scheduler{store, dockerClient, jobEnqueuer, interval, newCooldown(cooldownInterval)}
 }
 
 // Run starts the scheduler polling on an interval
@@ -41,13 +44,36 @@ func (s *Scheduler) Run() {
 func (s *Scheduler) poll() {
 	logger.Debug("Scheduler polling")
 
+	timer := prometheus.NewTimer(pollDuration)
+	defer timer.ObserveDuration()
+
 	deployments, err := deployment.GetAllDeployments()
 	if err != nil {
 		logger.Error(errors.Wrap(err, "Unhandled error when polling"))
 	}
 
 	for _, d := range deployments {
+		name := d.Config.Name
+
 		if hasDesiredState(d) {
+			s.cooldown.reset(name)
+			continue
+		}
+
+		driftTotal.WithLabelValues(name).Inc()
+
+		if s.cooldown.active(name, time.Now()) {
+			logger.Debugf("Deployment %s is not in its desired state, skipping reconcile while cooling down", name)
+			continue
+		}
+
+		// the cooldown starts before queuing the job since enqueuing blocks
+		// until the queue has capacity which could overlap with the next poll
+		s.cooldown.start(name, time.Now())
+
+		logger.Infof("Deployment %s is not in its desired state, scheduling reconcile", name)
+		if _, err := s.enqueuer.Enqueue(deployment.ReconcileJob(d.Config)); err != nil {
+			logger.Errorf("Unable to queue reconcile job %v", err)
 			continue
 		}
 	}
@@ -55,8 +81,13 @@ func (s *Scheduler) poll() {
 	logger.Debugf("Next poll in %s", s.interval.String())
 }
 
-// hasDesiredState checks that deployments are in parity with their configurations
+// hasDesiredState checks that deployments are in parity with their configurations,
+// deployments whose containers were stopped on purpose are left as they are
 func hasDesiredState(d deployment.Deployment) bool {
+	if d.Stopped {
+		return true
+	}
+
 	config := d.Config
 	containers := d.Containers
 
@@ -64,8 +95,10 @@ func hasDesiredState(d deployment.Deployment) bool {
 		return false
 	}
 
-	if err := deployment.RetriableContainersHealthCheck(containers, 3); err != nil {
-		return false
+	for _, c := range containers {
+		if !c.State.Running {
+			return false
+		}
 	}
 
 	return true
@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/deployment"
+)
+
+func TestHasDesiredState(t *testing.T) {
+	running := deployment.KraneContainer{State: deployment.ContainerState{Running: true}}
+	exited := deployment.KraneContainer{State: deployment.ContainerState{Running: false}}
+
+	d := deployment.Deployment{Config: deployment.Config{Scale: 2}, Containers: []deployment.KraneContainer{running, running}}
+	assert.True(t, hasDesiredState(d))
+
+	d.Containers = []deployment.KraneContainer{running}
+	assert.False(t, hasDesiredState(d))
+
+	d.Containers = []deployment.KraneContainer{running, exited}
+	assert.False(t, hasDesiredState(d))
+
+	// containers stopped on purpose are not reconciled
+	d.Containers = []deployment.KraneContainer{exited, exited}
+	d.Stopped = true
+	assert.True(t, hasDesiredState(d))
+}
@@ -0,0 +1,173 @@
+package session
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// unsupportedKeyOptions are authorized_keys options restricting a key in ways Krane cannot honour,
+// entries with these options are rejected rather than granting more access than intended
+var unsupportedKeyOptions = []string{"cert-authority", "command", "principals"}
+
+// AuthorizedKey is a public key allowed to authenticate, parsed from an authorized_keys entry
+type AuthorizedKey struct {
+	PublicKey crypto.PublicKey // *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+	Type      string           // ssh key type (ie. ssh-ed25519)
+	Comment   string           // usually identifies the key owner (ie. alice@example.com)
+	Options   []string         // options preceding the key (ie. no-pty, from="10.0.0.0/8")
+	From      []string         // address patterns the key can authenticate from (from=), empty for any address
+	ExpiresAt time.Time        // time after which the key can no longer authenticate (expiry-time=), zero if never
+}
+
+// IsExpired returns true once the expiry-time of the key is reached
+func (k AuthorizedKey) IsExpired() bool {
+	return !k.ExpiresAt.IsZero() && !time.Now().Before(k.ExpiresAt)
+}
+
+// AllowsAddress returns true if the key can authenticate a client connecting from an ip address. Like sshd,
+// the from= patterns are matched against the address (ie. 10.0.0.*, 192.168.0.0/16) and a negated
+// pattern (ie. !10.0.0.1) denies the address. Host names are not resolved and never match.
+func (k AuthorizedKey) AllowsAddress(addr string) bool {
+	if len(k.From) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(addr)
+	allowed := false
+	for _, pattern := range k.From {
+		negated := strings.HasPrefix(pattern, "!")
+		if matchAddress(strings.TrimPrefix(pattern, "!"), addr, ip) {
+			if negated {
+				return false
+			}
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// matchAddress matches an ip address against a cidr or wildcard pattern
+func matchAddress(pattern string, addr string, ip net.IP) bool {
+	if strings.Contains(pattern, "/") {
+		_, network, err := net.ParseCIDR(pattern)
+		return err == nil && ip != nil && network.Contains(ip)
+	}
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(addr))
+	return matched
+}
+
+// ParseAuthorizedKey parses an authorized_keys entry: [options] keytype base64-key [comment]
+func ParseAuthorizedKey(line string) (AuthorizedKey, error) {
+	pubKey, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return AuthorizedKey{}, err
+	}
+
+	cryptoKey, ok := pubKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return AuthorizedKey{}, errors.New("unsupported key type " + pubKey.Type())
+	}
+
+	key := AuthorizedKey{
+		PublicKey: cryptoKey.CryptoPublicKey(),
+		Type:      pubKey.Type(),
+		Comment:   strings.TrimSpace(comment),
+		Options:   options,
+	}
+
+	// jwt tokens can only be signed with rsa, ecdsa or ed25519 keys
+	switch key.PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return AuthorizedKey{}, errors.New("unsupported key type " + key.Type)
+	}
+
+	if err := key.parseOptions(); err != nil {
+		return AuthorizedKey{}, err
+	}
+
+	return key, nil
+}
+
+// parseOptions reads the from= and expiry-time= restrictions of the key, keys with
+// restrictions Krane cannot honour (ie. command=) return an error
+func (k *AuthorizedKey) parseOptions() error {
+	for _, option := range k.Options {
+		name, value := splitKeyOption(option)
+
+		for _, unsupported := range unsupportedKeyOptions {
+			if name == unsupported {
+				return fmt.Errorf("unsupported key option %s", name)
+			}
+		}
+
+		switch name {
+		case "from":
+			for _, pattern := range strings.Split(value, ",") {
+				if pattern = strings.TrimSpace(pattern); pattern != "" {
+					k.From = append(k.From, pattern)
+				}
+			}
+			if len(k.From) == 0 {
+				return errors.New("invalid key option from, no address patterns")
+			}
+		case "expiry-time":
+			expiresAt, err := parseKeyExpiryTime(value)
+			if err != nil {
+				return err
+			}
+			k.ExpiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+// splitKeyOption splits an option into its lowercased name and unquoted value (ie. from="10.0.0.0/8")
+func splitKeyOption(option string) (name string, value string) {
+	parts := strings.SplitN(option, "=", 2)
+	name = strings.ToLower(strings.TrimSpace(parts[0]))
+	if len(parts) == 2 {
+		value = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+	return name, value
+}
+
+// parseKeyExpiryTime parses an expiry-time formatted as YYYYMMDD[HHMM[SS]] in local time, or in UTC with a Z suffix
+func parseKeyExpiryTime(value string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(value, "Z") {
+		value = strings.TrimSuffix(value, "Z")
+		loc = time.UTC
+	}
+
+	layouts := map[int]string{8: "20060102", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid key option expiry-time %s", value)
+	}
+
+	expiresAt, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid key option expiry-time %s", value)
+	}
+	return expiresAt, nil
+}
+
+// DecodePublicKey decodes an authorized_keys entry into an rsa, ecdsa or ed25519 public key
+func DecodePublicKey(str string) (crypto.PublicKey, error) {
+	key, err := ParseAuthorizedKey(str)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey, nil
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// authorizedKeyLine returns the authorized_keys entry of a public key
+func authorizedKeyLine(t *testing.T, pubKey interface{}, comment string) string {
+	sshKey, err := ssh.NewPublicKey(pubKey)
+	assert.Nil(t, err)
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshKey))) + " " + comment
+}
+
+func signAuthToken(t *testing.T, method jwt.SigningMethod, privKey interface{}, phrase string) string {
+	tkn, err := jwt.NewWithClaims(method, &Claims{Phrase: phrase}).SignedString(privKey)
+	assert.Nil(t, err)
+	return tkn
+}
+
+func TestParseAuthorizedKey(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	line := `no-pty,from="10.0.0.0/8" ` + authorizedKeyLine(t, pubKey, "alice@example.com")
+	key, err := ParseAuthorizedKey(line)
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-ed25519", key.Type)
+	assert.Equal(t, "alice@example.com", key.Comment)
+	assert.Equal(t, []string{"no-pty", `from="10.0.0.0/8"`}, key.Options)
+	assert.Equal(t, pubKey, key.PublicKey)
+
+	_, err = ParseAuthorizedKey("ssh-ed25519 invalid alice@example.com")
+	assert.Error(t, err)
+}
+
+func TestParseAuthorizedKeyOptions(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	line := authorizedKeyLine(t, pubKey, "alice@example.com")
+
+	key, err := ParseAuthorizedKey(`from="10.0.0.0/8,192.168.1.*,!10.0.0.1",expiry-time="20990101120000Z" ` + line)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.*", "!10.0.0.1"}, key.From)
+	assert.Equal(t, time.Date(2099, 1, 1, 12, 0, 0, 0, time.UTC), key.ExpiresAt)
+	assert.False(t, key.IsExpired())
+
+	assert.True(t, key.AllowsAddress("10.1.2.3"))
+	assert.True(t, key.AllowsAddress("192.168.1.20"))
+	assert.False(t, key.AllowsAddress("10.0.0.1"))
+	assert.False(t, key.AllowsAddress("192.168.2.20"))
+
+	key, err = ParseAuthorizedKey(`expiry-time="20200101" ` + line)
+	assert.Nil(t, err)
+	assert.True(t, key.IsExpired())
+	assert.True(t, key.AllowsAddress("192.168.2.20"))
+
+	_, err = ParseAuthorizedKey(`expiry-time="tomorrow" ` + line)
+	assert.Error(t, err)
+
+	// restrictions krane cannot honour are rejected
+	for _, option := range []string{`command="echo hi"`, `principals="alice"`, "cert-authority"} {
+		_, err = ParseAuthorizedKey(option + " " + line)
+		assert.Error(t, err, option)
+	}
+}
+
+func TestVerifyAuthTokenWithEd25519Key(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	key, err := ParseAuthorizedKey(authorizedKeyLine(t, pubKey, "alice@example.com"))
+	assert.Nil(t, err)
+
+	tkn := signAuthToken(t, SigningMethodEdDSA, privKey, "phrase")
+	claims, authKey := VerifyAuthTokenWithAuthorizedKeys([]AuthorizedKey{key}, tkn)
+	assert.NotNil(t, claims)
+	assert.Equal(t, "phrase", claims.Phrase)
+	assert.Equal(t, "alice@example.com", authKey.Comment)
+}
+
+func TestVerifyAuthTokenWithECDSAKey(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	key, err := ParseAuthorizedKey(authorizedKeyLine(t, &privKey.PublicKey, "bob@example.com"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ecdsa-sha2-nistp256", key.Type)
+
+	tkn := signAuthToken(t, jwt.SigningMethodES256, privKey, "phrase")
+	claims, authKey := VerifyAuthTokenWithAuthorizedKeys([]AuthorizedKey{key}, tkn)
+	assert.NotNil(t, claims)
+	assert.Equal(t, "bob@example.com", authKey.Comment)
+}
+
+func TestVerifyAuthTokenFindsSigningKey(t *testing.T) {
+	otherPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	other, _ := ParseAuthorizedKey(authorizedKeyLine(t, otherPubKey, "other"))
+	key, _ := ParseAuthorizedKey(authorizedKeyLine(t, pubKey, "alice"))
+
+	tkn := signAuthToken(t, SigningMethodEdDSA, privKey, "phrase")
+	claims, authKey := VerifyAuthTokenWithAuthorizedKeys([]AuthorizedKey{other, key}, tkn)
+	assert.NotNil(t, claims)
+	assert.Equal(t, "alice", authKey.Comment)
+
+	// tokens signed by a key that is not authorized are rejected
+	claims, _ = VerifyAuthTokenWithAuthorizedKeys([]AuthorizedKey{other}, tkn)
+	assert.Nil(t, claims)
+}
+
+func TestDecodeJWTWithPubKeyRejectsMismatchedSigningMethod(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	_, err = DecodeJWTWithPubKey(&ecdsaKey.PublicKey, signAuthToken(t, SigningMethodEdDSA, privKey, "phrase"))
+	assert.Error(t, err)
+
+	// a token cannot be verified with an hmac of the public key
+	_, err = DecodeJWTWithPubKey(pubKey, signAuthToken(t, jwt.SigningMethodHS256, []byte(pubKey), "phrase"))
+	assert.Error(t, err)
+}
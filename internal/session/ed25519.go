@@ -0,0 +1,54 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEd25519 signs and verifies jwt tokens with ed25519 keys (EdDSA)
+type SigningMethodEd25519 struct{}
+
+// SigningMethodEdDSA is the EdDSA signing method, registered so tokens signed with ed25519 keys can be parsed
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the jwt algorithm name
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Verify checks the signature of a token with an ed25519 public key
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	pubKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pubKey, []byte(signingString), sig) {
+		return errors.New("ed25519 verification error")
+	}
+
+	return nil
+}
+
+// Sign signs a token with an ed25519 private key
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privKey, []byte(signingString))), nil
+}
@@ -1,14 +1,13 @@
 package session
 
 import (
-	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
-	"encoding/base64"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/big"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
@@ -45,21 +44,18 @@ func DecodeJWTToken(signKey string, tknStr string) (jwt.Token, error) {
 	return *tkn, nil
 }
 
-// DecodeJWTWithPubKey gets the claims of a jwt auth token
-func DecodeJWTWithPubKey(pubKey string, tknStr string) (claims jwt.Claims, err error) {
-
-	// convert ssh format pub key to rsa pub key
-	rsaPubKey, err := DecodePublicKey(pubKey)
-	if err != nil {
-		return
-	}
-
-	// validate token signed with private key against rsa public key
+// DecodeJWTWithPubKey gets the claims of a jwt auth token signed with the private key of an rsa, ecdsa or ed25519 public key
+func DecodeJWTWithPubKey(pubKey crypto.PublicKey, tknStr string) (claims jwt.Claims, err error) {
+	// validate token signed with private key against the public key, the signing
+	// method must match the key type so a token cannot pick how it is verified
 	tkn, err := jwt.ParseWithClaims(
 		tknStr,
 		&Claims{},
 		func(token *jwt.Token) (interface{}, error) {
-			return rsaPubKey, nil
+			if !signingMethodMatchesKey(token.Method, pubKey) {
+				return nil, fmt.Errorf("unexpected signing method %s for %T", token.Method.Alg(), pubKey)
+			}
+			return pubKey, nil
 		},
 	)
 
@@ -75,51 +71,41 @@ func DecodeJWTWithPubKey(pubKey string, tknStr string) (claims jwt.Claims, err e
 	return tkn.Claims, nil
 }
 
-// VerifyAuthTokenWithAuthorizedKeys gets the auth claims from jwt token using an authorized key from server
-func VerifyAuthTokenWithAuthorizedKeys(keys []string, tkn string) (claims *Claims) {
+// VerifyAuthTokenWithAuthorizedKeys gets the auth claims from jwt token using an authorized key from server,
+// the authorized key the token was signed with is returned along with the claims
+func VerifyAuthTokenWithAuthorizedKeys(keys []AuthorizedKey, tkn string) (*Claims, AuthorizedKey) {
 	for _, key := range keys {
-		c, err := DecodeJWTWithPubKey(key, tkn)
+		c, err := DecodeJWTWithPubKey(key.PublicKey, tkn)
 		if err != nil {
-			logger.Debugf("unable to decode JWT token with server private key %s", err.Error())
+			logger.Debugf("unable to decode JWT token with authorized key %s, %s", key.Type, err.Error())
 			continue
 		}
 
 		// map jwt claims into auth claims
-		claims, _ = c.(*Claims)
-		break
+		if claims, ok := c.(*Claims); ok {
+			return claims, key
+		}
 	}
 
-	return
+	return nil, AuthorizedKey{}
 }
 
-// DecodePublicKey decodes an ssh-rsa string into rsa public key
-func DecodePublicKey(str string) (*rsa.PublicKey, error) {
-	// comes in as a three part string
-	// split into component parts
-	tokens := strings.Split(str, " ")
-
-	if len(tokens) < 2 {
-		return nil, fmt.Errorf("Invalid key format; must contain at least two fields (keytype data [comment])")
-	}
-
-	keyType := tokens[0]
-	data, err := base64.StdEncoding.DecodeString(tokens[1])
-	if err != nil {
-		return nil, err
-	}
-
-	format, e, n, err := getRsaValues(data)
-
-	if format != keyType {
-		return nil, fmt.Errorf("Key type said %s, but encoded format said %s.  These should match", keyType, format)
-	}
-
-	pubKey := &rsa.PublicKey{
-		N: n,
-		E: int(e.Int64()),
-	}
-
-	return pubKey, nil
+// signingMethodMatchesKey returns true if a jwt signing method verifies signatures with a type of public key
+func signingMethodMatchesKey(method jwt.SigningMethod, pubKey crypto.PublicKey) bool {
+	switch pubKey.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*SigningMethodEd25519)
+		return ok
+	}
+	return false
 }
 
 // ParseTokenTypeAndValue returns the type and value of a jwt token
@@ -175,56 +161,3 @@ func IsValidTokenFormat(tkn string) bool {
 
 	return true
 }
-
-func readLength(data []byte) ([]byte, uint32, error) {
-	lBuf := data[0:4]
-
-	buf := bytes.NewBuffer(lBuf)
-
-	var length uint32
-
-	err := binary.Read(buf, binary.BigEndian, &length)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return data[4:], length, nil
-}
-
-func readBigInt(data []byte, length uint32) ([]byte, *big.Int, error) {
-	var bigint = new(big.Int)
-	bigint.SetBytes(data[0:length])
-	return data[length:], bigint, nil
-}
-
-func getRsaValues(data []byte) (format string, e *big.Int, n *big.Int, err error) {
-	data, length, err := readLength(data)
-	if err != nil {
-		return
-	}
-
-	format = string(data[0:length])
-	data = data[length:]
-
-	data, length, err = readLength(data)
-	if err != nil {
-		return
-	}
-
-	data, e, err = readBigInt(data, length)
-	if err != nil {
-		return
-	}
-
-	data, length, err = readLength(data)
-	if err != nil {
-		return
-	}
-
-	data, n, err = readBigInt(data, length)
-	if err != nil {
-		return
-	}
-
-	return
-}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+// the last use of a session is saved at most once per interval to limit writes to the db
+const lastUsedInterval = time.Minute
+
+// lastUse is the last time a session was used. Last uses are stored apart from the sessions so recording
+// a use never writes the session itself, a session deleted while in use cannot be saved again.
+type lastUse struct {
+	SessionID string `json:"session_id"`
+	UsedAt    string `json:"used_at"` // RFC3339
+}
+
+// Touch records the use of a session, the last use is saved at most once per minute
+func Touch(s Session) (Session, error) {
+	now := time.Now()
+	if lastUsedAt, err := time.Parse(time.RFC3339, GetLastUsedAt(s.ID)); err == nil && now.Sub(lastUsedAt) < lastUsedInterval {
+		s.LastUsedAt = lastUsedAt.Format(time.RFC3339)
+		return s, nil
+	}
+
+	s.LastUsedAt = now.Format(time.RFC3339)
+
+	bytes, err := store.Serialize(lastUse{SessionID: s.ID, UsedAt: s.LastUsedAt})
+	if err != nil {
+		return s, err
+	}
+
+	return s, store.Client().Put(constants.SessionsLastUsedCollectionName, s.ID, bytes)
+}
+
+// GetLastUsedAt returns the RFC3339 time a session was last used, empty if it was never used
+func GetLastUsedAt(id string) string {
+	bytes, err := store.Client().Get(constants.SessionsLastUsedCollectionName, id)
+	if err != nil || bytes == nil {
+		return ""
+	}
+
+	var use lastUse
+	if err := store.Deserialize(bytes, &use); err != nil {
+		return ""
+	}
+
+	return use.UsedAt
+}
+
+func deleteLastUse(id string) error {
+	return store.Client().Remove(constants.SessionsLastUsedCollectionName, id)
+}
+
+// deleteOrphanedLastUses removes the last uses of sessions which no longer exist, a use
+// recorded while its session was being deleted would otherwise be kept forever
+func deleteOrphanedLastUses(sessions []Session) error {
+	bytes, err := store.Client().GetAll(constants.SessionsLastUsedCollectionName)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		exists[s.ID] = true
+	}
+
+	orphaned := make([]string, 0)
+	for _, b := range bytes {
+		var use lastUse
+		if err := store.Deserialize(b, &use); err != nil {
+			continue
+		}
+
+		if !exists[use.SessionID] {
+			orphaned = append(orphaned, use.SessionID)
+		}
+	}
+
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	return store.Client().RemoveKeys(constants.SessionsLastUsedCollectionName, orphaned)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// length of the token prefix kept to identify a session token
+const tokenPrefixLength = 8
+
+// record is a session as stored in the db, only a hash of the session token is stored
+type record struct {
+	ID              string `json:"id"`
+	User            string `json:"user"`
+	Token           string `json:"token,omitempty"` // plaintext token of sessions saved before only a hash was stored
+	TokenHash       string `json:"token_hash"`
+	TokenPrefix     string `json:"token_prefix"`
+	CreatedAt       string `json:"created_at"`
+	ExpiresAt       string `json:"expires_at"`
+	AuthenticatedAt string `json:"authenticated_at"`
+	Role            Role   `json:"role"`
+	Scope           Scope  `json:"scope"`
+}
+
+// newRecord returns the record of a session, the session token is replaced by its hash
+func newRecord(s Session) record {
+	r := record{
+		ID:              s.ID,
+		User:            s.User,
+		TokenHash:       s.tokenHash,
+		TokenPrefix:     s.TokenPrefix,
+		CreatedAt:       s.CreatedAt,
+		ExpiresAt:       s.ExpiresAt,
+		AuthenticatedAt: s.AuthenticatedAt,
+		Role:            s.Role,
+		Scope:           s.Scope,
+	}
+
+	if s.Token != "" {
+		r.TokenHash = hashToken(s.Token)
+		r.TokenPrefix = tokenPrefix(s.Token)
+	}
+
+	return r
+}
+
+// session returns the session of a record, the session token is never known once stored
+func (r record) session() Session {
+	s := Session{
+		ID:              r.ID,
+		User:            r.User,
+		TokenPrefix:     r.TokenPrefix,
+		tokenHash:       r.TokenHash,
+		CreatedAt:       r.CreatedAt,
+		ExpiresAt:       r.ExpiresAt,
+		AuthenticatedAt: r.AuthenticatedAt,
+		Role:            r.Role,
+		Scope:           r.Scope,
+	}
+
+	if r.Token != "" {
+		s.tokenHash = hashToken(r.Token)
+		s.TokenPrefix = tokenPrefix(r.Token)
+	}
+
+	return s
+}
+
+// hasPlaintextToken returns true for records saved before only a hash of the token was stored
+func (r record) hasPlaintextToken() bool {
+	return r.Token != ""
+}
+
+// hashToken returns the hex encoded sha256 hash of a session token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenPrefix returns the first characters of the token signature, the header and claims
+// of session tokens are alike so the signature is what tells tokens apart
+func tokenPrefix(token string) string {
+	signature := token[strings.LastIndex(token, ".")+1:]
+	if len(signature) > tokenPrefixLength {
+		signature = signature[:tokenPrefixLength]
+	}
+	return signature
+}
+
+// MatchesToken returns true if a token is the token the session was created with
+func (s Session) MatchesToken(token string) bool {
+	if s.tokenHash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(s.tokenHash), []byte(hashToken(token))) == 1
+}
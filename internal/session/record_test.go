@@ -0,0 +1,96 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestSaveOnlyStoresTokenHash(t *testing.T) {
+	s, err := New(signingKey, "ci", DeployerRole, nil, time.Hour)
+	assert.Nil(t, err)
+
+	bytes, err := store.Client().Get(constants.SessionsCollectionName, s.ID)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(bytes), s.Token))
+	assert.True(t, strings.Contains(string(bytes), hashToken(s.Token)))
+}
+
+func TestSessionsWithPlaintextTokensAreMigrated(t *testing.T) {
+	token := "header.claims.signature"
+	legacy := record{ID: "legacy", User: "ci", Token: token, ExpiresAt: "01/25/2099"}
+	assert.Nil(t, saveRecord(legacy))
+
+	s, err := GetSessionByID(legacy.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, s.Token)
+	assert.Equal(t, "signatur", s.TokenPrefix)
+	assert.True(t, s.MatchesToken(token))
+
+	// the plaintext token is removed from the db
+	bytes, err := store.Client().Get(constants.SessionsCollectionName, legacy.ID)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(bytes), token))
+}
+
+func TestMatchesToken(t *testing.T) {
+	s := Session{tokenHash: hashToken("token")}
+	assert.True(t, s.MatchesToken("token"))
+	assert.False(t, s.MatchesToken("other"))
+	assert.False(t, s.MatchesToken(""))
+	assert.False(t, Session{}.MatchesToken(""))
+}
+
+func TestTokenPrefix(t *testing.T) {
+	assert.Equal(t, "abcdefgh", tokenPrefix("header.claims.abcdefghijk"))
+	assert.Equal(t, "abc", tokenPrefix("header.claims.abc"))
+}
+
+func TestTouch(t *testing.T) {
+	s, err := New(signingKey, "ci", ViewerRole, nil, time.Hour)
+	assert.Nil(t, err)
+
+	touched, err := Touch(s)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, touched.LastUsedAt)
+	assert.Equal(t, touched.LastUsedAt, GetLastUsedAt(s.ID))
+
+	// the session itself is not saved again
+	saved, err := GetSessionByID(s.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, saved.LastUsedAt)
+	assert.True(t, saved.MatchesToken(s.Token))
+
+	sessions, err := GetAllSessions()
+	assert.Nil(t, err)
+	for _, listed := range sessions {
+		if listed.ID == s.ID {
+			assert.Equal(t, touched.LastUsedAt, listed.LastUsedAt)
+		}
+	}
+
+	// deleting a session removes its last use
+	assert.Nil(t, Delete(s.ID))
+	assert.Empty(t, GetLastUsedAt(s.ID))
+}
+
+func TestTouchDeletedSessionIsNotSavedAgain(t *testing.T) {
+	s, err := New(signingKey, "ci", ViewerRole, nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, Delete(s.ID))
+
+	// a use recorded while the session is deleted never restores the session
+	_, err = Touch(s)
+	assert.Nil(t, err)
+	assert.False(t, Exist(s.ID))
+
+	// and the orphaned last use is removed by the sweeper
+	_, err = DeleteExpired()
+	assert.Nil(t, err)
+	assert.Empty(t, GetLastUsedAt(s.ID))
+}
@@ -0,0 +1,33 @@
+package session
+
+import "fmt"
+
+// Role grants a session access to a set of routes, roles are ordered from least to most privileged
+type Role string
+
+const (
+	ViewerRole   Role = "viewer"   // read deployments, containers, jobs, events and logs
+	DeployerRole Role = "deployer" // viewer access, plus creating, running and updating deployments and their jobs
+	AdminRole    Role = "admin"    // full access including secrets, sessions and container exec
+)
+
+// privilege ranks roles, a role is granted the access of every role with a lower rank
+var privilege = map[Role]int{
+	ViewerRole:   1,
+	DeployerRole: 2,
+	AdminRole:    3,
+}
+
+// ParseRole returns the role matching a name
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := privilege[role]; !ok {
+		return "", fmt.Errorf("invalid role %s, expected admin, deployer or viewer", name)
+	}
+	return role, nil
+}
+
+// Allows returns true if a role is granted the access of the required role
+func (r Role) Allows(required Role) bool {
+	return privilege[r] >= privilege[required]
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	for _, name := range []string{"admin", "deployer", "viewer"} {
+		role, err := ParseRole(name)
+		assert.Nil(t, err)
+		assert.Equal(t, Role(name), role)
+	}
+
+	_, err := ParseRole("root")
+	assert.EqualError(t, err, "invalid role root, expected admin, deployer or viewer")
+}
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, AdminRole.Allows(AdminRole))
+	assert.True(t, AdminRole.Allows(DeployerRole))
+	assert.True(t, AdminRole.Allows(ViewerRole))
+
+	assert.False(t, DeployerRole.Allows(AdminRole))
+	assert.True(t, DeployerRole.Allows(DeployerRole))
+	assert.True(t, DeployerRole.Allows(ViewerRole))
+
+	assert.False(t, ViewerRole.Allows(AdminRole))
+	assert.False(t, ViewerRole.Allows(DeployerRole))
+	assert.True(t, ViewerRole.Allows(ViewerRole))
+
+	assert.False(t, Role("").Allows(ViewerRole))
+}
+
+func TestSessionWithoutRoleKeepsAdminAccess(t *testing.T) {
+	assert.True(t, Session{}.HasRole(AdminRole))
+	assert.False(t, Session{Role: ViewerRole}.HasRole(DeployerRole))
+}
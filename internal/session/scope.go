@@ -0,0 +1,55 @@
+package session
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Scope restricts a session to the deployments matching a list of names or glob patterns (ie. payments-*),
+// an empty scope grants access to every deployment
+type Scope []string
+
+// ParseScope returns the scope of a comma separated list of deployment names or glob patterns. An empty value
+// is an unrestricted scope, a list without any pattern (ie. ",") is an error rather than granting every deployment.
+func ParseScope(value string) (Scope, error) {
+	scope := make(Scope, 0)
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid scope pattern %s", pattern)
+		}
+
+		scope = append(scope, pattern)
+	}
+
+	if value != "" && len(scope) == 0 {
+		return nil, fmt.Errorf("invalid scope %q, expected a comma separated list of deployment names or glob patterns", value)
+	}
+
+	return scope, nil
+}
+
+// Unrestricted returns true if the scope grants access to every deployment
+func (s Scope) Unrestricted() bool {
+	return len(s) == 0
+}
+
+// Allows returns true if a deployment is within the scope
+func (s Scope) Allows(deployment string) bool {
+	if s.Unrestricted() {
+		return true
+	}
+
+	for _, pattern := range s {
+		if matched, _ := path.Match(pattern, deployment); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScope(t *testing.T) {
+	scope, err := ParseScope("payments-*, api,,")
+	assert.Nil(t, err)
+	assert.Equal(t, Scope{"payments-*", "api"}, scope)
+
+	scope, err = ParseScope("")
+	assert.Nil(t, err)
+	assert.True(t, scope.Unrestricted())
+
+	// a list without any pattern does not grant every deployment
+	for _, value := range []string{",", " ", " , ,"} {
+		_, err = ParseScope(value)
+		assert.Error(t, err)
+	}
+
+	_, err = ParseScope("payments-[")
+	assert.EqualError(t, err, "invalid scope pattern payments-[")
+}
+
+func TestScopeAllows(t *testing.T) {
+	scope := Scope{"payments-*", "api"}
+	assert.True(t, scope.Allows("payments-worker"))
+	assert.True(t, scope.Allows("api"))
+	assert.False(t, scope.Allows("api-v2"))
+	assert.False(t, scope.Allows("web"))
+
+	assert.True(t, Scope{}.Allows("web"))
+	assert.True(t, Scope(nil).Allows("web"))
+}
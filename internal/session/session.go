@@ -6,19 +6,31 @@ import (
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/docker/distribution/uuid"
 	"github.com/sirupsen/logrus"
 
 	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/store"
-	"github.com/krane/krane/internal/utils"
 )
 
-// Session represents an authenticated user session
+// layout of the expiry date of sessions created before expiry was enforced (MM/DD/YYYY)
+const legacyExpiryLayout = "01/2/2006"
+
+// Session represents an authenticated user session. The session token is only known when the session is
+// created, only a hash of the token is stored and the token prefix is kept to tell sessions apart.
 type Session struct {
-	ID        string `json:"id"`
-	User      string `json:"user"`
-	Token     string `json:"token"`
-	ExpiresAt string `json:"expires_at"`
+	ID              string `json:"id"`
+	User            string `json:"user"`
+	Token           string `json:"token,omitempty"` // only set in the response creating the session
+	TokenPrefix     string `json:"token_prefix"`
+	CreatedAt       string `json:"created_at"`       // RFC3339 time the session was created
+	ExpiresAt       string `json:"expires_at"`       // RFC3339 time after which the session is rejected
+	AuthenticatedAt string `json:"authenticated_at"` // RFC3339 time of the login or creation the session was refreshed from
+	LastUsedAt      string `json:"last_used_at"`     // RFC3339 time the session was last used, within a minute
+	Role            Role   `json:"role"`
+	Scope           Scope  `json:"scope"` // deployments the session is restricted to, empty for every deployment
+	tokenHash       string
 }
 
 func (s Session) IsValid() bool {
@@ -30,16 +42,137 @@ func (s Session) IsValid() bool {
 		return false
 	}
 
-	if s.Token == "" {
+	if s.Token == "" && s.tokenHash == "" {
 		return false
 	}
 
-	// TODO: validate expiry date
-	return true
+	return !s.IsExpired()
+}
+
+// IsExpired returns true once the session expiry date has passed, sessions without a valid expiry date are expired
+func (s Session) IsExpired() bool {
+	expiresAt, err := s.Expiry()
+	if err != nil {
+		return true
+	}
+	return !time.Now().Before(expiresAt)
+}
+
+// Expiry returns the time after which the session is rejected
+func (s Session) Expiry() (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s.ExpiresAt); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(legacyExpiryLayout, s.ExpiresAt, time.Local)
+}
+
+// Lifetime returns the time to live the session was created with, 0 for sessions created without a creation date
+func (s Session) Lifetime() time.Duration {
+	createdAt, err := time.Parse(time.RFC3339, s.CreatedAt)
+	if err != nil {
+		return 0
+	}
+
+	expiresAt, err := s.Expiry()
+	if err != nil {
+		return 0
+	}
+	return expiresAt.Sub(createdAt)
+}
+
+// HasRole returns true if the session is granted the access of a role. Sessions created
+// before roles were introduced have no role and keep the admin access they were created with.
+func (s Session) HasRole(required Role) bool {
+	role := s.Role
+	if role == "" {
+		role = AdminRole
+	}
+	return role.Allows(required)
+}
+
+// New creates and saves a session with a signed token expiring after a time to live
+func New(signingKey string, user string, role Role, scope Scope, ttl time.Duration) (Session, error) {
+	return create(signingKey, Session{User: user, Role: role, Scope: scope}, ttl, time.Now())
+}
+
+// create saves a session with the user, role and scope of a session and a new signed token expiring after a time to live
+func create(signingKey string, from Session, ttl time.Duration, authenticatedAt time.Time) (Session, error) {
+	if ttl <= 0 {
+		return Session{}, errors.New("session ttl must be greater than 0")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	token := Token{SessionID: uuid.Generate().String()}
+	signedTkn, err := CreateSessionToken(signingKey, token, expiresAt)
+	if err != nil {
+		return Session{}, err
+	}
+
+	s := Session{
+		ID:              token.SessionID,
+		User:            from.User,
+		Token:           signedTkn,
+		TokenPrefix:     tokenPrefix(signedTkn),
+		CreatedAt:       now.Format(time.RFC3339),
+		ExpiresAt:       expiresAt.Format(time.RFC3339),
+		AuthenticatedAt: authenticatedAt.Format(time.RFC3339),
+		Role:            from.Role,
+		Scope:           from.Scope,
+		tokenHash:       hashToken(signedTkn),
+	}
+
+	if err := Save(s); err != nil {
+		return Session{}, err
+	}
+
+	return s, nil
+}
+
+// Refresh rotates the token of a session, a new session is created with the same user, role and scope
+// and the refreshed session is deleted so its token can no longer be used. The new session cannot live
+// longer than the refreshed session did and expires at the latest once the session max age is reached.
+func Refresh(signingKey string, s Session, ttl time.Duration) (Session, error) {
+	if s.IsExpired() {
+		return Session{}, errors.New("cannot refresh an expired session")
+	}
+
+	if lifetime := s.Lifetime(); lifetime > 0 && ttl > lifetime {
+		ttl = lifetime
+	}
+
+	// sessions created before refreshes were limited start their max age from their first refresh
+	authenticatedAt, err := time.Parse(time.RFC3339, s.AuthenticatedAt)
+	if err != nil {
+		authenticatedAt = time.Now()
+	}
+
+	remaining := time.Until(authenticatedAt.Add(SessionMaxAge()))
+	if remaining <= 0 {
+		return Session{}, errors.New("session reached its max age and cannot be refreshed, authenticate again")
+	}
+
+	if ttl > remaining {
+		ttl = remaining
+	}
+
+	refreshed, err := create(signingKey, s, ttl, authenticatedAt)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if err := Delete(s.ID); err != nil {
+		// the new session is removed so the refresh can be retried with the current token
+		_ = Delete(refreshed.ID)
+		return Session{}, err
+	}
+
+	return refreshed, nil
 }
 
 // CreateSessionToken creates a new jwt token used in a user session instance
-func CreateSessionToken(SigningKey string, sessionTkn Token) (string, error) {
+func CreateSessionToken(SigningKey string, sessionTkn Token, expiresAt time.Time) (string, error) {
 	if SigningKey == "" {
 		return "", errors.New("cannot create token - signing key not provided")
 	}
@@ -47,7 +180,7 @@ func CreateSessionToken(SigningKey string, sessionTkn Token) (string, error) {
 	customClaims := &CustomClaims{
 		Data: sessionTkn,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: utils.OneYear,
+			ExpiresAt: expiresAt.Unix(),
 			IssuedAt:  time.Now().Unix(),
 			Issuer:    "Krane",
 			Id:        sessionTkn.SessionID,
@@ -66,23 +199,30 @@ func CreateSessionToken(SigningKey string, sessionTkn Token) (string, error) {
 	return signedTkn, nil
 }
 
-// Save saves a user session into the db
+// Save saves a user session into the db, only a hash of the session token is saved
 func Save(session Session) error {
 	if session.ID == "" {
 		return errors.New("invalid session")
 	}
 
-	bytes, err := store.Serialize(session)
+	return saveRecord(newRecord(session))
+}
+
+func saveRecord(r record) error {
+	bytes, err := store.Serialize(r)
 	if err != nil {
 		return err
 	}
 
-	return store.Client().Put(constants.SessionsCollectionName, session.ID, bytes)
+	return store.Client().Put(constants.SessionsCollectionName, r.ID, bytes)
 }
 
-// Delete removes a user session from the db
+// Delete removes a user session and its last use from the db
 func Delete(id string) error {
-	return store.Client().Remove(constants.SessionsCollectionName, id)
+	if err := store.Client().Remove(constants.SessionsCollectionName, id); err != nil {
+		return err
+	}
+	return deleteLastUse(id)
 }
 
 // Exist returns true if a session exist in the db
@@ -111,16 +251,10 @@ func GetSessionByID(id string) (Session, error) {
 		return Session{}, fmt.Errorf("session not found")
 	}
 
-	var session Session
-	err = store.Deserialize(bytes, &session)
-	if err != nil {
-		return Session{}, err
-	}
-
-	return session, nil
+	return deserializeSession(bytes)
 }
 
-// GetAllSessions returns all user sessions
+// GetAllSessions returns all user sessions along with their last use
 func GetAllSessions() ([]Session, error) {
 	bytes, err := store.Client().GetAll(constants.SessionsCollectionName)
 	if err != nil {
@@ -129,14 +263,59 @@ func GetAllSessions() ([]Session, error) {
 
 	sessions := make([]Session, 0)
 	for _, session := range bytes {
-		var s Session
-		err := store.Deserialize(session, &s)
+		s, err := deserializeSession(session)
 		if err != nil {
 			return make([]Session, 0), err
 		}
 
+		s.LastUsedAt = GetLastUsedAt(s.ID)
 		sessions = append(sessions, s)
 	}
 
 	return sessions, nil
 }
+
+// DeleteExpired removes every expired session from the db and returns the amount of sessions removed,
+// the last uses recorded for sessions which no longer exist are removed as well
+func DeleteExpired() (int, error) {
+	sessions, err := GetAllSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := deleteOrphanedLastUses(sessions); err != nil {
+		logger.Warnf("unable to delete the last use of deleted sessions, %v", err)
+	}
+
+	deleted := 0
+	for _, s := range sessions {
+		if !s.IsExpired() {
+			continue
+		}
+
+		if err := Delete(s.ID); err != nil {
+			logger.Warnf("unable to delete expired session %s, %v", s.ID, err)
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// deserializeSession returns a stored session, sessions saved with a plaintext token are saved again with a hash
+func deserializeSession(bytes []byte) (Session, error) {
+	var r record
+	if err := store.Deserialize(bytes, &r); err != nil {
+		return Session{}, err
+	}
+
+	s := r.session()
+	if r.hasPlaintextToken() {
+		if err := Save(s); err != nil {
+			logger.Warnf("unable to remove the plaintext token of session %s, %v", s.ID, err)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/utils/test"
+)
+
+const signingKey = "something"
+
+func TestMain(m *testing.M) {
+	test.SetupDb()
+
+	code := m.Run()
+
+	test.TeardownDb()
+	os.Exit(code)
+}
+
+func TestSessionIsExpired(t *testing.T) {
+	s := Session{ID: "1", User: "ci", Token: "token", ExpiresAt: time.Now().Add(time.Minute).Format(time.RFC3339)}
+	assert.False(t, s.IsExpired())
+	assert.True(t, s.IsValid())
+
+	s.ExpiresAt = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	assert.True(t, s.IsExpired())
+	assert.False(t, s.IsValid())
+
+	// sessions without a valid expiry date are expired
+	s.ExpiresAt = ""
+	assert.True(t, s.IsExpired())
+}
+
+func TestSessionExpiryParsesLegacyDates(t *testing.T) {
+	s := Session{ExpiresAt: "01/25/2022"}
+	expiresAt, err := s.Expiry()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, time.January, 25, 0, 0, 0, 0, time.Local), expiresAt)
+	assert.True(t, s.IsExpired())
+}
+
+func TestSessionLifetime(t *testing.T) {
+	s := Session{CreatedAt: "2021-01-01T00:00:00Z", ExpiresAt: "2021-01-01T12:00:00Z"}
+	assert.Equal(t, 12*time.Hour, s.Lifetime())
+
+	// legacy sessions have no creation date
+	assert.Equal(t, time.Duration(0), Session{ExpiresAt: "01/25/2022"}.Lifetime())
+}
+
+func TestNewSession(t *testing.T) {
+	s, err := New(signingKey, "ci", DeployerRole, Scope{"api"}, time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, s.IsValid())
+	assert.Equal(t, time.Hour, s.Lifetime())
+
+	// the token is only returned when the session is created
+	saved, err := GetSessionByID(s.ID)
+	assert.Nil(t, err)
+	expected := s
+	expected.Token = ""
+	assert.Equal(t, expected, saved)
+	assert.True(t, saved.MatchesToken(s.Token))
+
+	// the token expires with the session
+	decodedTkn, err := DecodeJWTToken(signingKey, s.Token)
+	assert.Nil(t, err)
+	expiresAt, _ := s.Expiry()
+	assert.Equal(t, expiresAt.Unix(), decodedTkn.Claims.(*CustomClaims).ExpiresAt)
+
+	_, err = New(signingKey, "ci", DeployerRole, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestExpiredTokensAreRejected(t *testing.T) {
+	tkn, err := CreateSessionToken(signingKey, Token{SessionID: "1"}, time.Now().Add(-time.Minute))
+	assert.Nil(t, err)
+
+	_, err = DecodeJWTToken(signingKey, tkn)
+	assert.Error(t, err)
+}
+
+func TestRefreshSessionRotatesToken(t *testing.T) {
+	s, err := New(signingKey, "ci", ViewerRole, Scope{"api-*"}, time.Hour)
+	assert.Nil(t, err)
+
+	refreshed, err := Refresh(signingKey, s, 30*time.Minute)
+	assert.Nil(t, err)
+	assert.NotEqual(t, s.ID, refreshed.ID)
+	assert.NotEqual(t, s.Token, refreshed.Token)
+	assert.Equal(t, s.User, refreshed.User)
+	assert.Equal(t, s.Role, refreshed.Role)
+	assert.Equal(t, s.Scope, refreshed.Scope)
+	assert.Equal(t, s.AuthenticatedAt, refreshed.AuthenticatedAt)
+	assert.Equal(t, 30*time.Minute, refreshed.Lifetime())
+
+	// the refreshed session is revoked
+	_, err = GetSessionByID(s.ID)
+	assert.Error(t, err)
+	assert.True(t, Exist(refreshed.ID))
+}
+
+func TestRefreshSessionCannotExtendItsLifetime(t *testing.T) {
+	s, err := New(signingKey, "ci", ViewerRole, nil, time.Hour)
+	assert.Nil(t, err)
+
+	refreshed, err := Refresh(signingKey, s, 876000*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, refreshed.Lifetime())
+}
+
+func TestRefreshSessionCannotExceedMaxAge(t *testing.T) {
+	defer os.Unsetenv(constants.EnvSessionMaxAgeMs)
+	os.Setenv(constants.EnvSessionMaxAgeMs, "3600000")
+
+	s, err := New(signingKey, "ci", ViewerRole, nil, 24*time.Hour)
+	assert.Nil(t, err)
+
+	// sessions refreshed near the max age expire once it is reached
+	s.AuthenticatedAt = time.Now().Add(-30 * time.Minute).Format(time.RFC3339)
+	refreshed, err := Refresh(signingKey, s, 24*time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, refreshed.Lifetime() <= 30*time.Minute)
+	assert.Equal(t, s.AuthenticatedAt, refreshed.AuthenticatedAt)
+
+	refreshed.AuthenticatedAt = time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	_, err = Refresh(signingKey, refreshed, time.Hour)
+	assert.Error(t, err)
+	assert.True(t, Exist(refreshed.ID))
+}
+
+func TestRefreshExpiredSessionReturnsErr(t *testing.T) {
+	s := Session{ID: "expired", User: "ci", Token: "token", ExpiresAt: time.Now().Add(-time.Minute).Format(time.RFC3339)}
+	_, err := Refresh(signingKey, s, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestDeleteExpired(t *testing.T) {
+	active, err := New(signingKey, "ci", DeployerRole, nil, time.Hour)
+	assert.Nil(t, err)
+
+	expired := Session{ID: "expired", User: "ci", Token: "token", ExpiresAt: time.Now().Add(-time.Minute).Format(time.RFC3339)}
+	assert.Nil(t, Save(expired))
+
+	deleted, err := DeleteExpired()
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, deleted, 1)
+
+	_, err = GetSessionByID(expired.ID)
+	assert.Error(t, err)
+	assert.True(t, Exist(active.ID))
+}
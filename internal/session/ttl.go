@@ -0,0 +1,66 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/utils"
+)
+
+const (
+	// default time to live of sessions created with krane login
+	defaultLoginTTL = 24 * time.Hour
+	// default time to live of access tokens created with the sessions api
+	defaultAccessTokenTTL = 7 * 24 * time.Hour
+	// default time after a login or the creation of an access token sessions can be refreshed until
+	defaultSessionMaxAge = 7 * 24 * time.Hour
+)
+
+// LoginTTL returns the time to live of sessions created when logging in (SESSION_TTL_MS), at most the session max age
+func LoginTTL() time.Duration {
+	return minDuration(utils.DurationEnvMs(constants.EnvSessionTTLMs, defaultLoginTTL), SessionMaxAge())
+}
+
+// AccessTokenTTL returns the time to live of access tokens created with the sessions api (ACCESS_TOKEN_TTL_MS),
+// at most the session max age so access tokens stay short-lived
+func AccessTokenTTL() time.Duration {
+	return minDuration(utils.DurationEnvMs(constants.EnvAccessTokenTTLMs, defaultAccessTokenTTL), SessionMaxAge())
+}
+
+// SessionMaxAge returns the time after a login or the creation of an access token the session and the sessions
+// refreshed from it expire at the latest (SESSION_MAX_AGE_MS)
+func SessionMaxAge() time.Duration {
+	return utils.DurationEnvMs(constants.EnvSessionMaxAgeMs, defaultSessionMaxAge)
+}
+
+// ParseTTL parses a session time to live formatted as a duration (ie. 30m, 12h). The ttl cannot exceed
+// the max time to live, an empty value returns the max time to live.
+func ParseTTL(value string, max time.Duration) (time.Duration, error) {
+	if value == "" {
+		return max, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("invalid ttl, expected a duration such as 30m or 12h")
+	}
+
+	if ttl <= 0 {
+		return 0, errors.New("ttl must be greater than 0")
+	}
+
+	if ttl > max {
+		return 0, fmt.Errorf("ttl cannot exceed %s", max.String())
+	}
+
+	return ttl, nil
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+func TestParseTTL(t *testing.T) {
+	ttl, err := ParseTTL("", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, ttl)
+
+	ttl, err = ParseTTL("30m", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Minute, ttl)
+
+	_, err = ParseTTL("tomorrow", time.Hour)
+	assert.Error(t, err)
+
+	_, err = ParseTTL("-1h", time.Hour)
+	assert.Error(t, err)
+
+	_, err = ParseTTL("876000h", time.Hour)
+	assert.Error(t, err)
+}
+
+func TestLoginTTL(t *testing.T) {
+	defer os.Unsetenv(constants.EnvSessionTTLMs)
+
+	assert.Equal(t, defaultLoginTTL, LoginTTL())
+
+	os.Setenv(constants.EnvSessionTTLMs, "60000")
+	assert.Equal(t, time.Minute, LoginTTL())
+
+	os.Setenv(constants.EnvSessionTTLMs, "0")
+	assert.Equal(t, defaultLoginTTL, LoginTTL())
+}
+
+func TestAccessTokenTTLIsCappedAtMaxAge(t *testing.T) {
+	defer os.Unsetenv(constants.EnvAccessTokenTTLMs)
+	defer os.Unsetenv(constants.EnvSessionMaxAgeMs)
+
+	assert.Equal(t, defaultAccessTokenTTL, AccessTokenTTL())
+
+	os.Setenv(constants.EnvAccessTokenTTLMs, "31536000000")
+	assert.Equal(t, defaultSessionMaxAge, AccessTokenTTL())
+
+	os.Setenv(constants.EnvSessionMaxAgeMs, "3600000")
+	assert.Equal(t, time.Hour, AccessTokenTTL())
+	assert.Equal(t, time.Hour, LoginTTL())
+}
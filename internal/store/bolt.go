@@ -155,6 +155,24 @@ func (b *BoltDB) Remove(collection string, key string) error {
 	})
 }
 
+// RemoveKeys : remove several keys from a bucket in a single transaction
+func (b *BoltDB) RemoveKeys(collection string, keys []string) error {
+	return instance.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(collection))
+		if bkt == nil {
+			// dont return err if bkt does not exists
+			return nil
+		}
+
+		for _, key := range keys {
+			if err := bkt.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (b *BoltDB) DeleteCollection(collection string) error {
 	return instance.Update(func(tx *bolt.Tx) error {
 		return tx.DeleteBucket([]byte(collection))
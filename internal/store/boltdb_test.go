@@ -157,3 +157,20 @@ func TestBoltGetAll(t *testing.T) {
 		assert.NotNil(t, hero.CreatedAt)
 	}
 }
+
+func TestBoltRemoveKeys(t *testing.T) {
+	bkt := "remove-keys-test"
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, Client().Put(bkt, key, []byte(key)))
+	}
+
+	assert.Nil(t, Client().RemoveKeys(bkt, []string{"a", "c"}))
+
+	data, err := Client().GetAll(bkt)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("b")}, data)
+
+	// removing keys from a missing collection is not an error
+	assert.Nil(t, Client().RemoveKeys("missing-collection", []string{"a"}))
+	assert.Nil(t, Client().DeleteCollection(bkt))
+}
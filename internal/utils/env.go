@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // RequireEnv exits the program if environment vairables not set
@@ -81,6 +82,20 @@ func UIntEnv(key string) uint {
 	return uint(v)
 }
 
+// DurationEnvMs returns the duration of an environment variable expressed in milliseconds,
+// or the fallback if not found, invalid or not greater than 0
+func DurationEnvMs(key string, fallback time.Duration) time.Duration {
+	value, found := os.LookupEnv(key)
+	if !found {
+		return fallback
+	}
+	d, err := time.ParseDuration(value + "ms")
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
 // IntEnv returns the int environment variable or 0 if not found
 func IntEnv(key string) int {
 	value, found := os.LookupEnv(key)